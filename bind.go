@@ -0,0 +1,180 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vedranvuk/commandline/internal/naming"
+)
+
+// Bind registers a Param for each exported field of a struct pointed to by
+// v in these Parameters, in order of field declaration. Field values are
+// the Go values of the Params and their current values are the defaults.
+//
+// Param is defined by field tags:
+//
+//	cmd:"name,short,options..." help:"Help text" default:"value" env:"A,B" choices:"a,b"
+//
+// Name is the long name of the Param and if empty it is the field name in
+// kebab-case, e.g. "ListenPort" becomes "listen-port". Short is the optional
+// single char short name. Options are:
+//
+//	required	Param is required.
+//	raw		Param is a raw param, see AddRawParam.
+//	repeated	Param can be repeated and field must be a slice, see AddRepeatedParam.
+//	counter		Param is a counter and field must be an int, see AddCounterParam.
+//	negatable	Param is negatable and field must be a bool, see AddNegatableParam.
+//	variadic	Param is variadic and field must be a slice, see AddVariadicParam.
+//
+// Other fields set the Param help, default value, environment variables and
+// choices. A bool field that is not negatable or raw is a flag that takes no
+// value, see AddFlag. A field tagged with cmd:"-" is skipped.
+//
+// Fields of struct type that do not implement Value or
+// encoding.TextUnmarshaler are bound recursively with long names of their
+// Params prefixed by the name of the field and a dot, e.g. "db.host".
+// Embedded structs are bound without a prefix. Fields of pointer to such
+// struct types are not supported.
+//
+// If an error occurs it is ErrRegister or a descendant, the Param of the
+// failed field is not registered and Params of fields preceding it remain
+// registered.
+func (p *Parameters) Bind(v interface{}) error {
+	var rv = reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: bind requires a pointer to a struct", ErrRegister)
+	}
+	return p.bind(rv.Elem(), "")
+}
+
+// MustBind is like Bind except the function panics on error.
+// Returns a Command that the params were added to.
+func (p *Parameters) MustBind(v interface{}) *Command {
+	var err error
+	if err = p.Bind(v); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// bind registers Params for fields of struct v with long names prefixed by
+// prefix.
+func (p *Parameters) bind(v reflect.Value, prefix string) error {
+	var t = v.Type()
+	var field reflect.StructField
+	var value reflect.Value
+	var tag, long, short, option string
+	var options []string
+	var tagged bool
+	var err error
+	for i := 0; i < t.NumField(); i++ {
+		if field, value = t.Field(i), v.Field(i); field.PkgPath != "" {
+			continue
+		}
+		if tag, tagged = field.Tag.Lookup("cmd"); tag == "-" {
+			continue
+		}
+		options = strings.Split(tag, ",")
+		if long, options = options[0], options[1:]; long == "" {
+			long = naming.KebabCase(field.Name)
+		}
+		if field.Type.Kind() == reflect.Ptr && isNested(field.Type.Elem()) {
+			return fmt.Errorf("%w: field '%s' is a pointer to a struct", ErrRegister, field.Name)
+		}
+		if isNested(field.Type) && len(options) == 0 {
+			if field.Anonymous && !tagged {
+				err = p.bind(value, prefix)
+			} else {
+				err = p.bind(value, prefix+long+".")
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		long, short = prefix+long, ""
+		if len(options) > 0 && len(options[0]) <= 1 {
+			short, options = options[0], options[1:]
+		}
+		var param = NewParameter(field.Tag.Get("help"), false, false, value.Addr().Interface())
+		for _, option = range options {
+			switch option {
+			case "required":
+				param.required = true
+			case "raw":
+				param.raw = true
+			case "repeated":
+				param.repeated = true
+			case "counter":
+				if _, ok := param.value.(*int); !ok {
+					return fmt.Errorf("%w: counter field '%s' must be an int", ErrRegister, field.Name)
+				}
+				param.counted = true
+			case "negatable":
+				if _, ok := param.value.(*bool); !ok {
+					return fmt.Errorf("%w: negatable field '%s' must be a bool", ErrRegister, field.Name)
+				}
+				param.negatable = true
+			case "variadic":
+				param.raw, param.repeated, param.variadic = true, true, true
+			default:
+				return fmt.Errorf("%w: field '%s' has invalid option '%s'", ErrRegister, field.Name, option)
+			}
+		}
+		if _, ok := param.value.(*bool); ok && !param.negatable && !param.raw {
+			param.flag = true
+		}
+		if param.variadic && param.required {
+			param.min = 1
+		}
+		if err = p.addParam(long, short, param); err != nil {
+			return err
+		}
+		if err = p.bindTag(long, field.Tag); err != nil {
+			p.removeParam(long)
+			return err
+		}
+	}
+	return nil
+}
+
+// bindTag sets the default value, environment variables and choices of a
+// Param registered under long name from field tag.
+func (p *Parameters) bindTag(long string, tag reflect.StructTag) error {
+	var value string
+	var ok bool
+	var err error
+	if value, ok = tag.Lookup("default"); ok {
+		if err = p.SetDefault(long, value); err != nil {
+			return err
+		}
+	}
+	if value = tag.Get("env"); value != "" {
+		if err = p.SetEnv(long, strings.Split(value, ",")...); err != nil {
+			return err
+		}
+	}
+	if value = tag.Get("choices"); value != "" {
+		if err = p.SetChoices(long, strings.Split(value, ",")...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNested returns true if t is a struct type whose fields are bound as
+// params instead of the struct itself.
+func isNested(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	var ptr = reflect.PtrTo(t)
+	return !ptr.Implements(reflect.TypeOf((*Value)(nil)).Elem()) &&
+		!ptr.Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
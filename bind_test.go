@@ -0,0 +1,113 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"errors"
+	"testing"
+)
+
+// Test binding a struct to Parameters.
+func TestBind(t *testing.T) {
+	type Database struct {
+		Host string `cmd:",,required" help:"Database host"`
+		Port int    `default:"5432"`
+	}
+	type Common struct {
+		Verbose int `cmd:"verbose,v,counter"`
+	}
+	var options struct {
+		Common
+		ListenPort int      `cmd:"port,p" help:"Listen port" env:"PORT"`
+		Format     string   `cmd:"format,f" choices:"json,yaml"`
+		Color      bool     `cmd:"color,,negatable"`
+		Debug      bool     `cmd:"debug,d"`
+		Tags       []string `cmd:"tag,t,repeated"`
+		DB         Database `cmd:"db"`
+		Skipped    string   `cmd:"-"`
+		Input      string   `cmd:"input,raw,required"`
+		Files      []string `cmd:"files,variadic"`
+		unexported string
+	}
+	options.Format = "json"
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", nil).MustBind(&options)
+	var params = cl.MustGetCommand("foo").Parameters
+	if params.ParameterCount() != 10 {
+		t.Fatal("Invalid number of bound params.")
+	}
+	if err = cl.Parse([]string{"foo", "-vvd", "--port", "80", "--no-color", "-t", "a", "-t", "b",
+		"--db.host", "localhost", "in", "x", "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if options.Verbose != 2 || options.ListenPort != 80 || options.Format != "json" || options.Color || !options.Debug ||
+		len(options.Tags) != 2 || options.DB.Host != "localhost" || options.DB.Port != 5432 ||
+		options.Input != "in" || len(options.Files) != 2 {
+		t.Fatal("Values not bound.")
+	}
+	if err = cl.Parse([]string{"foo", "-f", "xml", "--db.host", "h", "in"}); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatal("Failed binding choices.")
+	}
+	if err = cl.Parse([]string{"foo", "in"}); !errors.Is(err, ErrParse) {
+		t.Fatal("Failed binding required.")
+	}
+	if err = cl.Parse([]string{"foo", "--debug", "--db.host", "h", "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if !options.Debug || options.Input != "true" {
+		t.Fatal("Bool field not bound as a flag.")
+	}
+	var tests = []interface{}{
+		nil,
+		options,
+		new(int),
+		&struct {
+			A int `cmd:"a,,counter"`
+			B int `cmd:"a"`
+		}{},
+		&struct {
+			A string `cmd:"a,,counter"`
+		}{},
+		&struct {
+			A bool `cmd:"a,,invalid"`
+		}{},
+		&struct {
+			A string `cmd:"a,raw"`
+			B string `cmd:"b"`
+		}{},
+		&struct {
+			A *struct{ B int }
+		}{},
+	}
+	for _, test := range tests {
+		if err = NewState().MustAddCommand("foo", "", nil).Bind(test); !errors.Is(err, ErrRegister) {
+			t.Fatal("Failed detecting invalid binding.")
+		}
+	}
+	// Param is not registered if a tag is invalid.
+	var cmd = NewState().MustAddCommand("foo", "", nil)
+	if err = cmd.Bind(&struct {
+		A int `cmd:"a,x" default:"3" choices:"1,2"`
+	}{}); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting default not in choices.")
+	}
+	if _, ok := cmd.GetParameter("a"); ok || cmd.ParameterCount() != 0 {
+		t.Fatal("Param with invalid tag registered.")
+	}
+	if err = cmd.Bind(&struct {
+		A int `cmd:"a,x"`
+	}{}); err != nil {
+		t.Fatal(err)
+	}
+	// Defaults are converted when parsing.
+	cl = NewState()
+	cl.MustAddCommand("foo", "", nil).MustBind(&struct {
+		A int `cmd:"a" default:"x"`
+	}{})
+	if err = cl.Parse([]string{"foo"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid bound default.")
+	}
+}
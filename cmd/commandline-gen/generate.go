@@ -0,0 +1,824 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vedranvuk/commandline"
+	"github.com/vedranvuk/commandline/internal/naming"
+	"github.com/vedranvuk/strconvex"
+)
+
+const (
+	// commandDirective annotates a struct type that defines a Command.
+	commandDirective = "//commandline:command"
+	// handlerDirective annotates a method that is a Command handler.
+	handlerDirective = "//commandline:handler"
+	// commandlinePath is the import path of the commandline package.
+	commandlinePath = "github.com/vedranvuk/commandline"
+)
+
+// command is a Command definition parsed from an annotated struct type.
+type command struct {
+	// path is the path of Command names, last being the Command name.
+	path []string
+	// typename is the name of the annotated type.
+	typename string
+	// help is the Command help.
+	help string
+	// raw specifies if the Command is raw.
+	raw bool
+	// handler is the name of the handler method, empty if none.
+	handler string
+	// params are Command Parameters in order of fields.
+	params []*param
+	// children are sub Commands in order of declaration.
+	children []*command
+	// value is the name of the generated value argument.
+	value string
+	// variable is the name of the generated Command variable, empty if the
+	// Command is not referenced after registration.
+	variable string
+	// pos is the position of the annotated type.
+	pos token.Position
+}
+
+// param is a Parameter definition parsed from a struct field.
+type param struct {
+	// long and short are the Param names.
+	long, short string
+	// help is the Param help.
+	help string
+	// field is the field selector relative to the Command value.
+	field string
+	// Param options.
+	required, raw, repeated, counter, negatable, variadic bool
+	// flag specifies if the Param is a bool flag.
+	flag bool
+	// def is the default value if hasdef is true.
+	def    string
+	hasdef bool
+	// env are environment variable names.
+	env []string
+	// choices are allowed values.
+	choices []string
+	// typ is the field type, nil if it cannot be resolved.
+	typ reflect.Type
+	// pos is the position of the field.
+	pos token.Position
+}
+
+// generator generates registration code for a package.
+type generator struct {
+	fset *token.FileSet
+	// pkg is the package name.
+	pkg string
+	// types are all types declared in the package by name.
+	types map[string]ast.Expr
+	// structs are all struct types declared in the package by name.
+	structs map[string]*ast.StructType
+	// methods are names of methods declared in the package by receiver
+	// type name.
+	methods map[string]map[string]bool
+	// commands are Commands in order of declaration.
+	commands []*command
+	// roots are Commands without a parent in order of declaration.
+	roots []*command
+}
+
+// generate parses the package in dir, excluding test files and output, and
+// returns formatted source of the generated function named funcname.
+func generate(dir, output, funcname string) ([]byte, error) {
+	var g = &generator{
+		fset:    token.NewFileSet(),
+		types:   make(map[string]ast.Expr),
+		structs: make(map[string]*ast.StructType),
+		methods: make(map[string]map[string]bool),
+	}
+	var pkgs, err = parser.ParseDir(g.fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != output
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected a single package in '%s', found %d", dir, len(pkgs))
+	}
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		var names []string
+		for name := range pkg.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, pkg.Files[name])
+		}
+		g.pkg = pkg.Name
+	}
+	if err = g.parse(files); err != nil {
+		return nil, err
+	}
+	if err = g.check(); err != nil {
+		return nil, err
+	}
+	return g.emit(funcname)
+}
+
+// parse parses commands from files.
+func (g *generator) parse(files []*ast.File) error {
+	var file *ast.File
+	var decl ast.Decl
+	var err error
+	// Types and commands.
+	for _, file = range files {
+		for _, decl = range file.Decls {
+			var gen, ok = decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				var ts = spec.(*ast.TypeSpec)
+				g.types[ts.Name.Name] = ts.Type
+				var st, ok = ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				g.structs[ts.Name.Name] = st
+				var doc = ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				if err = g.parseCommand(ts, doc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	// Methods and handlers.
+	for _, file = range files {
+		for _, decl = range file.Decls {
+			var fn, ok = decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			var recv = embeddedName(fn.Recv.List[0].Type)
+			if g.methods[recv] == nil {
+				g.methods[recv] = make(map[string]bool)
+			}
+			g.methods[recv][fn.Name.Name] = true
+			if !hasDirective(fn.Doc, handlerDirective) {
+				continue
+			}
+			if err = g.parseHandler(fn, importName(file, commandlinePath)); err != nil {
+				return err
+			}
+		}
+	}
+	// Params.
+	for _, cmd := range g.commands {
+		if err = g.parseParams(cmd, g.structs[cmd.typename], "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCommand parses a command from ts if doc contains a command
+// directive.
+func (g *generator) parseCommand(ts *ast.TypeSpec, doc *ast.CommentGroup) error {
+	if doc == nil {
+		return nil
+	}
+	var cmd *command
+	var args []string
+	for _, comment := range doc.List {
+		if !isDirective(comment.Text, commandDirective) {
+			continue
+		}
+		if cmd != nil {
+			return fmt.Errorf("%s: multiple command directives", g.fset.Position(comment.Pos()))
+		}
+		cmd = &command{
+			typename: ts.Name.Name,
+			pos:      g.fset.Position(ts.Pos()),
+		}
+		args = strings.Fields(comment.Text[len(commandDirective):])
+		if len(args) > 0 && args[len(args)-1] == "raw" && len(args) > 1 {
+			cmd.raw, args = true, args[:len(args)-1]
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("%s: invalid command directive", g.fset.Position(comment.Pos()))
+		}
+		var name = naming.KebabCase(ts.Name.Name)
+		if len(args) > 0 {
+			name = args[0]
+		}
+		cmd.path = strings.Split(name, ".")
+	}
+	if cmd == nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "commandline:") {
+			lines = append(lines, line)
+		}
+	}
+	cmd.help = strings.Join(lines, " ")
+	g.commands = append(g.commands, cmd)
+	return nil
+}
+
+// parseHandler parses a handler method fn declared in a file that imports
+// the commandline package as pkg.
+func (g *generator) parseHandler(fn *ast.FuncDecl, pkg string) error {
+	var pos = g.fset.Position(fn.Pos())
+	var recv = fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	var ident, ok = recv.(*ast.Ident)
+	if !ok {
+		return fmt.Errorf("%s: invalid handler receiver", pos)
+	}
+	if !isHandlerType(fn.Type, pkg) {
+		return fmt.Errorf("%s: handler '%s' must be a func(commandline.Context) error", pos, fn.Name.Name)
+	}
+	for _, cmd := range g.commands {
+		if cmd.typename != ident.Name {
+			continue
+		}
+		if cmd.handler != "" {
+			return fmt.Errorf("%s: multiple handlers for command type '%s'", pos, ident.Name)
+		}
+		cmd.handler = fn.Name.Name
+		return nil
+	}
+	return fmt.Errorf("%s: handler '%s' receiver is not a command type", pos, fn.Name.Name)
+}
+
+// isHandlerType returns true if ft is a func(commandline.Context) error
+// where pkg is the name the commandline package is imported as.
+func isHandlerType(ft *ast.FuncType, pkg string) bool {
+	if ft.Params.NumFields() != 1 || ft.Results.NumFields() != 1 {
+		return false
+	}
+	var sel, ok = ft.Params.List[0].Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Context" {
+		return false
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || pkg == "" || x.Name != pkg {
+		return false
+	}
+	var ident *ast.Ident
+	ident, ok = ft.Results.List[0].Type.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+// importName returns the name under which file imports the package at path
+// or an empty string if file does not import it.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if value, err := strconv.Unquote(spec.Path.Value); err != nil || value != path {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
+
+// parseParams parses params of cmd from fields of st. Prefix is the long
+// name prefix and selector the field selector prefix of nested structs.
+func (g *generator) parseParams(cmd *command, st *ast.StructType, prefix, selector string) error {
+	for _, field := range st.Fields.List {
+		var names []string
+		var anonymous = len(field.Names) == 0
+		if anonymous {
+			names = append(names, embeddedName(field.Type))
+		}
+		for _, ident := range field.Names {
+			names = append(names, ident.Name)
+		}
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			var s, err = strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return fmt.Errorf("%s: invalid tag", g.fset.Position(field.Pos()))
+			}
+			tag = reflect.StructTag(s)
+		}
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+			if err := g.parseParam(cmd, field, tag, anonymous, name, prefix, selector); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseParam parses a param of cmd from a field named name with tag.
+func (g *generator) parseParam(cmd *command, field *ast.Field, tag reflect.StructTag, anonymous bool, name, prefix, selector string) error {
+	var pos = g.fset.Position(field.Pos())
+	var value, tagged = tag.Lookup("cmd")
+	if value == "-" {
+		return nil
+	}
+	var options = strings.Split(value, ",")
+	var long = options[0]
+	if options = options[1:]; long == "" {
+		long = naming.KebabCase(name)
+	}
+	if star, ok := field.Type.(*ast.StarExpr); ok && g.nested(star.X) != nil {
+		return fmt.Errorf("%s: field '%s' is a pointer to a struct", pos, name)
+	}
+	if nested := g.nested(field.Type); nested != nil && len(options) == 0 {
+		if anonymous && !tagged {
+			return g.parseParams(cmd, nested, prefix, selector+name+".")
+		}
+		return g.parseParams(cmd, nested, prefix+long+".", selector+name+".")
+	}
+	var p = &param{
+		long:  prefix + long,
+		help:  tag.Get("help"),
+		field: selector + name,
+		pos:   pos,
+	}
+	if len(options) > 0 && len(options[0]) <= 1 {
+		p.short, options = options[0], options[1:]
+	}
+	for _, option := range options {
+		switch option {
+		case "required":
+			p.required = true
+		case "raw":
+			p.raw = true
+		case "repeated":
+			if !isSlice(field.Type) {
+				return fmt.Errorf("%s: repeated field '%s' must be a slice", pos, name)
+			}
+			p.repeated = true
+		case "counter":
+			if !isIdent(field.Type, "int") {
+				return fmt.Errorf("%s: counter field '%s' must be an int", pos, name)
+			}
+			p.counter = true
+		case "negatable":
+			if !isIdent(field.Type, "bool") {
+				return fmt.Errorf("%s: negatable field '%s' must be a bool", pos, name)
+			}
+			p.negatable = true
+		case "variadic":
+			if !isSlice(field.Type) {
+				return fmt.Errorf("%s: variadic field '%s' must be a slice", pos, name)
+			}
+			p.raw, p.variadic = true, true
+		default:
+			return fmt.Errorf("%s: field '%s' has invalid option '%s'", pos, name, option)
+		}
+	}
+	if isIdent(field.Type, "bool") && !p.negatable && !p.raw {
+		p.flag = true
+	}
+	p.typ = g.resolve(field.Type)
+	p.def, p.hasdef = tag.Lookup("default")
+	if value = tag.Get("env"); value != "" {
+		p.env = strings.Split(value, ",")
+	}
+	if value = tag.Get("choices"); value != "" {
+		p.choices = strings.Split(value, ",")
+	}
+	cmd.params = append(cmd.params, p)
+	return nil
+}
+
+// basicTypes are predeclared types by name that values can be converted to
+// when generating.
+var basicTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"string":  reflect.TypeOf(""),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"byte":    reflect.TypeOf(byte(0)),
+	"rune":    reflect.TypeOf(rune(0)),
+}
+
+// resolve returns a type of the same kind as the type expr if values can be
+// converted to it when generating or nil if not. Predeclared types, types
+// declared in the package based on them and slices of such types are
+// resolved. Types implementing commandline.Value or
+// encoding.TextUnmarshaler and types from other packages are not.
+func (g *generator) resolve(expr ast.Expr) reflect.Type {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if typ, ok := basicTypes[t.Name]; ok {
+			return typ
+		}
+		var methods = g.methods[t.Name]
+		if (methods["Set"] && methods["String"]) || methods["UnmarshalText"] {
+			return nil
+		}
+		if underlying, ok := g.types[t.Name]; ok {
+			return g.resolve(underlying)
+		}
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return nil
+		}
+		if elem := g.resolve(t.Elt); elem != nil && elem.Kind() != reflect.Slice {
+			return reflect.SliceOf(elem)
+		}
+	}
+	return nil
+}
+
+// nested returns the struct type of a field of type expr whose fields are
+// params or nil if the field is a param. As in Bind, struct literals and
+// structs declared in the package are nested unless they implement
+// commandline.Value or encoding.TextUnmarshaler, detected by method names.
+// Structs declared in other packages are not nested.
+func (g *generator) nested(expr ast.Expr) *ast.StructType {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		var methods = g.methods[t.Name]
+		if (methods["Set"] && methods["String"]) || methods["UnmarshalText"] {
+			return nil
+		}
+		return g.structs[t.Name]
+	case *ast.StructType:
+		return t
+	}
+	return nil
+}
+
+// check builds the command tree and checks commands and params are valid
+// for registration.
+func (g *generator) check() error {
+	if len(g.commands) == 0 {
+		return fmt.Errorf("no annotated command types found")
+	}
+	var bypath = make(map[string]*command)
+	var cmd *command
+	var key string
+	for _, cmd = range g.commands {
+		for _, name := range cmd.path {
+			if name == "" {
+				return fmt.Errorf("%s: invalid command name '%s'", cmd.pos, strings.Join(cmd.path, "."))
+			}
+		}
+		if key = strings.Join(cmd.path, "."); bypath[key] != nil {
+			return fmt.Errorf("%s: duplicate command '%s'", cmd.pos, key)
+		}
+		bypath[key] = cmd
+	}
+	for _, cmd = range g.commands {
+		if len(cmd.path) == 1 {
+			g.roots = append(g.roots, cmd)
+			continue
+		}
+		var parent = bypath[strings.Join(cmd.path[:len(cmd.path)-1], ".")]
+		if parent == nil {
+			return fmt.Errorf("%s: parent of command '%s' not found", cmd.pos, strings.Join(cmd.path, "."))
+		}
+		parent.children = append(parent.children, cmd)
+	}
+	// Register definitions as generated code does so that registration
+	// rules are checked by commandline.
+	var state = commandline.NewState()
+	for _, cmd = range g.roots {
+		if err := cmd.register(state.Commands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// register registers c and its' children with commands.
+func (c *command) register(commands *commandline.Commands) error {
+	var cmd *commandline.Command
+	var handler commandline.Handler
+	var err error
+	if c.handler != "" {
+		handler = func(commandline.Context) error { return nil }
+	}
+	if name := c.path[len(c.path)-1]; c.raw {
+		cmd, err = commands.AddRawCommand(name, c.help, handler)
+	} else {
+		cmd, err = commands.AddCommand(name, c.help, handler)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", c.pos, err)
+	}
+	for _, p := range c.params {
+		if err = p.register(cmd.Parameters); err != nil {
+			return fmt.Errorf("%s: %v", p.pos, err)
+		}
+	}
+	for _, child := range c.children {
+		if err = child.register(cmd.Commands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// method returns the name of the Parameters method that registers p.
+func (p *param) method() string {
+	switch {
+	case p.variadic:
+		return "AddVariadicParam"
+	case p.raw:
+		return "AddRawParam"
+	case p.counter:
+		return "AddCounterParam"
+	case p.negatable:
+		return "AddNegatableParam"
+	case p.flag:
+		return "AddFlag"
+	case p.repeated:
+		return "AddRepeatedParam"
+	}
+	return "AddParam"
+}
+
+// setRequired returns true if p is required but registered as optional by
+// its' method.
+func (p *param) setRequired() bool {
+	return p.required && (p.counter || p.negatable || p.flag)
+}
+
+// min returns the minimum number of arguments of a variadic param.
+func (p *param) min() int {
+	if p.required {
+		return 1
+	}
+	return 0
+}
+
+// register registers p with params using placeholder Go values of the kind
+// generated code uses and checks its' default value and choices convert to
+// the field type.
+func (p *param) register(params *commandline.Parameters) error {
+	var err error
+	if err = p.checkValues(); err != nil {
+		return err
+	}
+	var value interface{} = new(string)
+	if p.multiple() {
+		value = new([]string)
+	}
+	if p.typ != nil {
+		value = reflect.New(p.typ).Interface()
+	}
+	switch p.method() {
+	case "AddVariadicParam":
+		err = params.AddVariadicParam(p.long, p.help, p.min(), 0, value)
+	case "AddRawParam":
+		err = params.AddRawParam(p.long, p.help, p.required, value)
+	case "AddCounterParam":
+		err = params.AddCounterParam(p.long, p.short, p.help, new(int))
+	case "AddNegatableParam":
+		err = params.AddNegatableParam(p.long, p.short, p.help, new(bool))
+	case "AddFlag":
+		err = params.AddFlag(p.long, p.short, p.help, new(bool))
+	case "AddRepeatedParam":
+		err = params.AddRepeatedParam(p.long, p.short, p.help, p.required, value)
+	default:
+		err = params.AddParam(p.long, p.short, p.help, p.required, value)
+	}
+	if err == nil && p.setRequired() {
+		err = params.SetRequired(p.long, true)
+	}
+	if err == nil && p.hasdef {
+		err = params.SetDefault(p.long, p.def)
+	}
+	if err == nil && len(p.env) > 0 {
+		err = params.SetEnv(p.long, p.env...)
+	}
+	if err == nil && len(p.choices) > 0 {
+		err = params.SetChoices(p.long, p.choices...)
+	}
+	return err
+}
+
+// multiple returns true if p takes multiple values.
+func (p *param) multiple() bool { return p.repeated || p.variadic }
+
+// checkValues checks the default value and choices of p convert to the field
+// type. Fields whose type cannot be resolved cannot have either.
+func (p *param) checkValues() error {
+	if !p.hasdef && len(p.choices) == 0 {
+		return nil
+	}
+	if p.typ == nil {
+		return fmt.Errorf("default value and choices of field '%s' cannot be checked, field type is not supported", p.field)
+	}
+	if p.hasdef {
+		if err := strconvex.StringToInterface(p.def, reflect.New(p.typ).Interface()); err != nil {
+			return fmt.Errorf("default value '%s' of field '%s': %v", p.def, p.field, err)
+		}
+	}
+	var elem = p.typ
+	if p.multiple() {
+		elem = elem.Elem()
+	}
+	for _, choice := range p.choices {
+		if err := strconvex.StringToInterface(choice, reflect.New(elem).Interface()); err != nil {
+			return fmt.Errorf("choice '%s' of field '%s': %v", choice, p.field, err)
+		}
+	}
+	return nil
+}
+
+// emit returns formatted source of the generated function named funcname.
+func (g *generator) emit(funcname string) ([]byte, error) {
+	var used = map[string]bool{"commands": true, "err": true, "commandline": true}
+	var cmd *command
+	var args, vars []string
+	for _, cmd = range g.commands {
+		cmd.value = uniqueName(used, lowerCamel(cmd.typename))
+		args = append(args, fmt.Sprintf("%s *%s", cmd.value, cmd.typename))
+	}
+	for _, cmd = range g.commands {
+		if len(cmd.params) > 0 || len(cmd.children) > 0 {
+			cmd.variable = uniqueName(used, cmd.value+"Cmd")
+			vars = append(vars, cmd.variable)
+		}
+	}
+	var buf = &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by commandline-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", g.pkg)
+	fmt.Fprintf(buf, "import %q\n\n", commandlinePath)
+	fmt.Fprintf(buf, "// %s registers Commands defined by annotated types with commands using\n", funcname)
+	fmt.Fprintf(buf, "// specified values as Go values of their Parameters.\n")
+	fmt.Fprintf(buf, "func %s(commands *commandline.Commands, %s) error {\n", funcname, strings.Join(args, ", "))
+	fmt.Fprintf(buf, "var err error\n")
+	if len(vars) > 0 {
+		fmt.Fprintf(buf, "var %s *commandline.Command\n", strings.Join(vars, ", "))
+	}
+	for _, cmd = range g.roots {
+		emitCommand(buf, cmd, "commands")
+	}
+	fmt.Fprintf(buf, "return nil\n}\n")
+	return format.Source(buf.Bytes())
+}
+
+// emitCommand writes registration of cmd and its' children to buf. Parent
+// is the expression of Commands cmd is registered with.
+func emitCommand(buf *bytes.Buffer, cmd *command, parent string) {
+	var variable, handler, method = "_", "nil", "AddCommand"
+	if cmd.variable != "" {
+		variable = cmd.variable
+	}
+	if cmd.handler != "" {
+		handler = cmd.value + "." + cmd.handler
+	}
+	if cmd.raw {
+		method = "AddRawCommand"
+	}
+	fmt.Fprintf(buf, "// %s\n", strings.Join(cmd.path, " "))
+	emitCall(buf, fmt.Sprintf("%s, err = %s.%s(%s, %s, %s)", variable, parent, method,
+		strconv.Quote(cmd.path[len(cmd.path)-1]), strconv.Quote(cmd.help), handler))
+	for _, p := range cmd.params {
+		var value = "&" + cmd.value + "." + p.field
+		var long, short, help = strconv.Quote(p.long), strconv.Quote(p.short), strconv.Quote(p.help)
+		var args string
+		switch p.method() {
+		case "AddVariadicParam":
+			args = fmt.Sprintf("%s, %s, %d, 0, %s", long, help, p.min(), value)
+		case "AddRawParam":
+			args = fmt.Sprintf("%s, %s, %t, %s", long, help, p.required, value)
+		case "AddCounterParam", "AddNegatableParam", "AddFlag":
+			args = fmt.Sprintf("%s, %s, %s, %s", long, short, help, value)
+		default:
+			args = fmt.Sprintf("%s, %s, %s, %t, %s", long, short, help, p.required, value)
+		}
+		emitCall(buf, fmt.Sprintf("err = %s.%s(%s)", cmd.variable, p.method(), args))
+		if p.setRequired() {
+			emitCall(buf, fmt.Sprintf("err = %s.SetRequired(%s, true)", cmd.variable, long))
+		}
+		if p.hasdef {
+			emitCall(buf, fmt.Sprintf("err = %s.SetDefault(%s, %s)", cmd.variable, long, strconv.Quote(p.def)))
+		}
+		if len(p.env) > 0 {
+			emitCall(buf, fmt.Sprintf("err = %s.SetEnv(%s, %s)", cmd.variable, long, quoteAll(p.env)))
+		}
+		if len(p.choices) > 0 {
+			emitCall(buf, fmt.Sprintf("err = %s.SetChoices(%s, %s)", cmd.variable, long, quoteAll(p.choices)))
+		}
+	}
+	for _, child := range cmd.children {
+		emitCommand(buf, child, cmd.variable)
+	}
+}
+
+// emitCall writes a call statement that returns on error to buf.
+func emitCall(buf *bytes.Buffer, stmt string) {
+	fmt.Fprintf(buf, "if %s; err != nil {\nreturn err\n}\n", stmt)
+}
+
+// quoteAll returns values quoted and comma separated.
+func quoteAll(values []string) string {
+	var quoted = make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// uniqueName returns name or name suffixed with a number if name is used
+// or a keyword and marks it used.
+func uniqueName(used map[string]bool, name string) string {
+	var result = name
+	for i := 2; used[result] || token.Lookup(result).IsKeyword(); i++ {
+		result = name + strconv.Itoa(i)
+	}
+	used[result] = true
+	return result
+}
+
+// hasDirective returns true if doc contains directive.
+func hasDirective(doc *ast.CommentGroup, directive string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, comment := range doc.List {
+		if isDirective(comment.Text, directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDirective returns true if comment text is directive, with or without
+// arguments.
+func isDirective(text, directive string) bool {
+	return text == directive || strings.HasPrefix(text, directive+" ")
+}
+
+// embeddedName returns the field name of an embedded field of type expr.
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// isIdent returns true if expr is an identifier name.
+func isIdent(expr ast.Expr, name string) bool {
+	var ident, ok = expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// isSlice returns true if expr is a slice type.
+func isSlice(expr ast.Expr) bool {
+	var array, ok = expr.(*ast.ArrayType)
+	return ok && array.Len == nil
+}
+
+// lowerCamel returns s converted from CamelCase to lowerCamelCase, e.g.
+// "HTTPServer" to "httpServer".
+func lowerCamel(s string) string {
+	var words = strings.Split(naming.KebabCase(s), "-")
+	for i := 1; i < len(words); i++ {
+		var runes = []rune(words[i])
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
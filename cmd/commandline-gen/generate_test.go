@@ -0,0 +1,160 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Test generated code against golden files.
+func TestGenerate(t *testing.T) {
+	var dirs, err = ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		var src []byte
+		if src, err = generate(filepath.Join("testdata", dir.Name()), "commandline_gen.go", "RegisterCommands"); err != nil {
+			t.Fatal(err)
+		}
+		var golden = filepath.Join("testdata", dir.Name()+".golden")
+		if *update {
+			if err = ioutil.WriteFile(golden, src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		var expected []byte
+		if expected, err = ioutil.ReadFile(golden); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, expected) {
+			t.Fatalf("Output of %s does not match golden file:\n%s", dir.Name(), src)
+		}
+	}
+}
+
+// Test generated code compiles and agrees with Bind by running the tests
+// of testdata packages copied to a temporary module with the generated file.
+func TestGenerateBuild(t *testing.T) {
+	var gobin, err = exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	var root string
+	if root, err = filepath.Abs(filepath.Join("..", "..")); err != nil {
+		t.Fatal(err)
+	}
+	var sum []byte
+	if sum, err = ioutil.ReadFile(filepath.Join(root, "go.sum")); err != nil {
+		t.Fatal(err)
+	}
+	var dirs, files []os.FileInfo
+	if dirs, err = ioutil.ReadDir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		var pkgdir, tmpdir = filepath.Join("testdata", dir.Name()), t.TempDir()
+		if files, err = ioutil.ReadDir(pkgdir); err != nil {
+			t.Fatal(err)
+		}
+		var data []byte
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			if data, err = ioutil.ReadFile(filepath.Join(pkgdir, file.Name())); err != nil {
+				t.Fatal(err)
+			}
+			if err = ioutil.WriteFile(filepath.Join(tmpdir, file.Name()), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var mod = "module example\n\ngo 1.15\n\nrequire github.com/vedranvuk/commandline v0.0.0\n\n" +
+			"replace github.com/vedranvuk/commandline => " + root + "\n"
+		if err = ioutil.WriteFile(filepath.Join(tmpdir, "go.mod"), []byte(mod), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(tmpdir, "go.sum"), sum, 0644); err != nil {
+			t.Fatal(err)
+		}
+		var src []byte
+		if src, err = generate(tmpdir, "commandline_gen.go", "RegisterCommands"); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(tmpdir, "commandline_gen.go"), src, 0644); err != nil {
+			t.Fatal(err)
+		}
+		var cmd = exec.Command(gobin, "test", ".")
+		cmd.Dir = tmpdir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		var out []byte
+		if out, err = cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Testing %s failed: %v\n%s", dir.Name(), err, out)
+		}
+	}
+}
+
+// Test detecting invalid definitions.
+func TestGenerateErrors(t *testing.T) {
+	var tests = []struct {
+		src string
+		err string
+	}{
+		{"type A struct{}", "no annotated command types"},
+		{"//commandline:command a\ntype A struct{}\n//commandline:command a\ntype B struct{}", "duplicate command 'a'"},
+		{"//commandline:command a.b\ntype A struct{}", "parent of command 'a.b' not found"},
+		{"//commandline:command a b c\ntype A struct{}", "invalid command directive"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x\"`; Y int `cmd:\"x\"` }", "duplicate: long parameter name 'x'"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,s\"`; Y int `cmd:\"y,s\"` }", "duplicate: short parameter name 's'"},
+		{"//commandline:command a\ntype A struct{ X bool `cmd:\"x,,negatable\"`; Y int `cmd:\"no-x\"` }", "duplicate: long parameter name 'no-x'"},
+		{"//commandline:command a\ntype A struct{ X string `cmd:\"x,,counter\"` }", "counter field 'X' must be an int"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,,negatable\"` }", "negatable field 'X' must be a bool"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,,repeated\"` }", "repeated field 'X' must be a slice"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,,invalid\"` }", "invalid option 'invalid'"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,raw\"`; Y int `cmd:\"y\"` }", "prefixed parameter after raw parameter"},
+		{"//commandline:command a\ntype A struct{ X []int `cmd:\"x,variadic\"`; Y int `cmd:\"y,raw\"` }", "after variadic parameter"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,raw\"` }\n//commandline:command a.b\ntype B struct{}", "command with raw parameters cannot have sub commands"},
+		{"//commandline:command a raw\ntype A struct{}", "raw command must have a handler"},
+		{"//commandline:command a\ntype A struct{ X *B }\ntype B struct{ Y int }", "field 'X' is a pointer to a struct"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x\" default:\"1\" choices:\"2,3\"` }", "is not one of choices"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x,,counter\" choices:\"1,2\"` }", "does not take a value"},
+		{"//commandline:command a\ntype A struct{ X int `cmd:\"x\" default:\"a\"` }", "default value 'a' of field 'X'"},
+		{"//commandline:command a\ntype A struct{ X []int `cmd:\"x,,repeated\" choices:\"1,a\"` }", "choice 'a' of field 'X'"},
+		{"//commandline:command a\ntype A struct{ X B `cmd:\"x\" default:\"a\"` }\ntype B struct{}\nfunc (b *B) UnmarshalText([]byte) error { return nil }", "field type is not supported"},
+		{"//commandline:command a\ntype A struct{}\n//commandline:handler\nfunc (a A) H(x int) error { return nil }", "must be a func(commandline.Context) error"},
+		{"//commandline:command a\ntype A struct{}\n//commandline:handler\nfunc (a A) H(x commandline.Context) bool { return false }", "must be a func(commandline.Context) error"},
+		{"//commandline:command a\ntype A struct{}\n//commandline:handler\nfunc (a A) H(x context.Context) error { return nil }", "must be a func(commandline.Context) error"},
+		{"import \"github.com/vedranvuk/commandline\"\n//commandline:command a\ntype A struct{}\n//commandline:handler\nfunc (b B) H(x commandline.Context) error { return nil }", "receiver is not a command type"},
+	}
+	for _, test := range tests {
+		var dir, err = ioutil.TempDir("", "commandline-gen")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err = ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"+test.src+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = generate(dir, "commandline_gen.go", "RegisterCommands"); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("Failed detecting '%s', got: %v", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Command commandline-gen generates code that registers commandline Commands
+// and Parameters from annotated Go structs of a package, without reflection.
+//
+// A struct type annotated with a "commandline:command" directive defines a
+// Command whose Parameters are the struct fields:
+//
+//	// Serve starts the server.
+//	//commandline:command serve
+//	type Serve struct {
+//		Port int `cmd:"port,p,required" help:"Listen port"`
+//	}
+//
+//	//commandline:handler
+//	func (s *Serve) Run(ctx commandline.Context) error { ... }
+//
+// Directive takes the Command name, a dot separated path for sub Commands
+// of other annotated Commands, e.g. "remote.add", and an optional "raw"
+// option which registers a raw Command. If the name is omitted it is the
+// type name in kebab-case. Doc comment of the type is the Command help.
+// A method of the type annotated with a "commandline:handler" directive is
+// the Command handler.
+//
+// Field tags are the same as of commandline.Parameters.Bind, including
+// nested structs declared in the package and embedded structs. Structs with
+// Set and String or UnmarshalText methods are Parameters, not nested, and
+// structs declared in other packages are never nested. Definitions are
+// registered with commandline when generating so registration errors are
+// reported by the generator instead of the generated code. Default values
+// and choices are converted to the field type, which must be a basic type,
+// a type declared in the package based on one, or a slice of those; they
+// cannot be set on fields of other types. Handler must be a
+// func(commandline.Context) error.
+//
+// Generated function takes the Commands to register with followed by a
+// pointer to a value of each annotated type, in order of declaration:
+//
+//	func RegisterCommands(commands *commandline.Commands, serve *Serve) error
+//
+// Usage:
+//
+//	//go:generate go run github.com/vedranvuk/commandline/cmd/commandline-gen
+//
+// Flags:
+//
+//	-dir	package directory (default ".")
+//	-output	output file name in package directory (default "commandline_gen.go")
+//	-func	generated function name (default "RegisterCommands")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	var dir = flag.String("dir", ".", "package directory")
+	var output = flag.String("output", "commandline_gen.go", "output file name in package directory")
+	var funcname = flag.String("func", "RegisterCommands", "generated function name")
+	flag.Parse()
+	var src, err = generate(*dir, *output, *funcname)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "commandline-gen: %v\n", err)
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(filepath.Join(*dir, *output), src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "commandline-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,106 @@
+package example
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vedranvuk/commandline"
+)
+
+// Database holds database options.
+type Database struct {
+	Host string `cmd:",,required" help:"Database host"`
+	Port int    `default:"5432"`
+}
+
+// Level is a log level that implements commandline.Value.
+type Level struct {
+	Name string
+}
+
+// Set implements commandline.Value.
+func (l *Level) Set(s string) error {
+	if s != "debug" && s != "info" {
+		return fmt.Errorf("invalid level '%s'", s)
+	}
+	l.Name = s
+	return nil
+}
+
+// String implements commandline.Value.
+func (l *Level) String() string { return l.Name }
+
+// Address is a host:port address that implements encoding.TextUnmarshaler.
+type Address struct {
+	Host, Port string
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Address) UnmarshalText(text []byte) error {
+	var i = strings.LastIndexByte(string(text), ':')
+	if i < 0 {
+		return fmt.Errorf("invalid address '%s'", text)
+	}
+	a.Host, a.Port = string(text[:i]), string(text[i+1:])
+	return nil
+}
+
+// Mode is a serving mode.
+type Mode string
+
+// Common holds options shared by commands.
+type Common struct {
+	Verbose int `cmd:"verbose,v,counter" help:"Be more verbose."`
+}
+
+// Serve starts the server.
+//
+//commandline:command serve
+type Serve struct {
+	Common
+	ListenPort int      `cmd:"port,p" help:"Listen port" env:"PORT,SERVE_PORT"`
+	Format     string   `cmd:"format,f" choices:"json,yaml"`
+	Color      bool     `cmd:"color,,negatable"`
+	Confirm    bool     `cmd:"confirm,,negatable,required"`
+	DryRun     bool     `cmd:"dry-run,n"`
+	Level      Level    `cmd:"level,l"`
+	Mode       Mode     `cmd:"mode" default:"fast" choices:"fast,slow"`
+	Listen     Address  `cmd:"listen,,required"`
+	Tags       []string `cmd:"tag,t,repeated"`
+	DB         Database `cmd:"db"`
+	Skipped    string   `cmd:"-"`
+	Root       string   `cmd:"root,raw,required" help:"Root directory"`
+	Files      []string `cmd:"files,variadic"`
+	unexported string
+}
+
+// Run handles the serve command.
+//
+//commandline:handler
+func (s *Serve) Run(ctx commandline.Context) error { return nil }
+
+// Remote manages remotes.
+//
+//commandline:command
+type Remote struct{}
+
+// RemoteAdd adds a remote.
+//
+//commandline:command remote.add
+type RemoteAdd struct {
+	Name string `cmd:"name,raw,required"`
+	URL  string `cmd:"url,raw,required"`
+}
+
+// Exec executes a remote command.
+//
+//commandline:command remote.exec raw
+type Exec struct{}
+
+//commandline:handler
+func (e Exec) Handle(ctx commandline.Context) error { return nil }
+
+// notACommand is not annotated.
+type notACommand struct {
+	Field int
+}
@@ -0,0 +1,48 @@
+package example
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vedranvuk/commandline"
+)
+
+// Test generated registration agrees with Bind. The test is run by the
+// commandline-gen tests with the generated file in place.
+func TestRegisterCommands(t *testing.T) {
+	var generated, bound = commandline.NewState(), commandline.NewState()
+	var gserve, bserve Serve
+	var err error
+	if err = RegisterCommands(generated.Commands, &gserve, &Remote{}, &RemoteAdd{}, &Exec{}); err != nil {
+		t.Fatal(err)
+	}
+	var cmd *commandline.Command
+	if cmd, err = bound.AddCommand("serve", "Serve starts the server.", bserve.Run); err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Bind(&bserve); err != nil {
+		t.Fatal(err)
+	}
+	if g, b := generated.MustGetCommand("serve").Print(), cmd.Print(); g != b {
+		t.Fatalf("Generated parameters:\n%s\ndo not match bound parameters:\n%s", g, b)
+	}
+	var args = []string{"serve", "-vvn", "--confirm", "--db.host", "localhost", "--level", "debug", "--listen", "localhost:80", "/srv", "a", "b"}
+	if err = generated.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err = bound.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gserve, bserve) {
+		t.Fatalf("Generated value %+v does not match bound value %+v", gserve, bserve)
+	}
+	if !gserve.Confirm || !gserve.DryRun || gserve.Level.Name != "debug" || gserve.Listen.Port != "80" {
+		t.Fatalf("Failed parsing %+v", gserve)
+	}
+	for _, state := range []*commandline.State{generated, bound} {
+		if err = state.Parse([]string{"serve", "--db.host", "localhost", "--listen", "localhost:80", "/srv"}); !errors.Is(err, commandline.ErrParse) {
+			t.Fatalf("Failed detecting required negatable parameter, got: %v", err)
+		}
+	}
+}
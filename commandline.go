@@ -6,10 +6,14 @@
 package commandline
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/vedranvuk/strconvex"
@@ -43,6 +47,9 @@ var (
 	ErrRegister = fmt.Errorf("%w: register", ErrCommandline)
 	// ErrDuplicate help
 	ErrDuplicate = fmt.Errorf("%w: duplicate", ErrRegister)
+	// ErrHandlerSignature is returned when registering a handler function of
+	// an unsupported signature.
+	ErrHandlerSignature = fmt.Errorf("%w: invalid handler signature", ErrRegister)
 
 	// ErrParse is the base parse error.
 	ErrParse = fmt.Errorf("%w: parse error", ErrCommandline)
@@ -56,6 +63,16 @@ var (
 	ErrNoDefinitions = fmt.Errorf("%w: no definitions", ErrParse)
 	// ErrNotFound is returned when a Command or Parameter is not found.
 	ErrNotFound = fmt.Errorf("%w: not found", ErrParse)
+	// ErrAmbiguous is returned when an abbreviated Command or Parameter name
+	// matches multiple Commands or Parameters.
+	ErrAmbiguous = fmt.Errorf("%w: ambiguous", ErrParse)
+	// ErrInvalidChoice is returned when a parameter value is not one of
+	// parameter choices.
+	ErrInvalidChoice = fmt.Errorf("%w: invalid choice", ErrParse)
+	// ErrValidate is returned when a parameter or command validator fails.
+	ErrValidate = fmt.Errorf("%w: validation failed", ErrParse)
+	// ErrNotImplemented is returned by generated handler stubs.
+	ErrNotImplemented = fmt.Errorf("%w: not implemented", ErrCommandline)
 	// ErrDuplicateParameter is returned when a duplicate parameter was parsed.
 	ErrDuplicateParameter = fmt.Errorf("%w: parameter repeats", ErrParse)
 	// ErrExtraArguments is returned when extra arguments are specified and
@@ -64,6 +81,10 @@ var (
 )
 
 // Context is a CommandFunc context that provides info about Command execution.
+//
+// Context is implemented by this package and methods are added to it as
+// features are added, which breaks implementations outside of the package.
+// Such implementations, e.g. in tests, should embed a Context.
 type Context interface {
 	// Arguments returns unparsed command line arguments if command is raw.
 	Arguments() []string
@@ -76,12 +97,34 @@ type Context interface {
 	// specified name. If parameter was not parsed or is not registered 
 	// an empty string is returned.
 	Value(string) string
+	// Values returns all raw string arguments given to a parameter under
+	// specified name in order as they were parsed. Useful for repeated
+	// parameters. If parameter was not parsed or is not registered an empty
+	// slice is returned.
+	Values(string) []string
+	// Default returns the default value of a parameter under specified name
+	// as a string. If parameter has no default value or is not registered an
+	// empty string is returned.
+	Default(string) string
+	// Count returns the number of times a counter parameter under specified
+	// name was specified. If parameter was not parsed, is not registered or
+	// is not a counter 0 is returned.
+	Count(string) int
+	// Get returns the Go value of a parameter under specified name. If
+	// parameter has no Go value the count of a counter, raw values of a
+	// repeated parameter or the raw value is returned. If parameter is not
+	// registered nil is returned.
+	Get(string) interface{}
 	// Executed will be true if context is from a handler whose command is the
 	// last command in the chain matched from command line.
 	Executed() bool
 	// Parsed returns true if the parameter under specified long name is defined
 	// and parsed from command line and false otherwise.
 	Parsed(string) bool
+	// Source returns the source of the value of a parameter under specified
+	// name. If parameter has no value or is not registered SourceNone is
+	// returned.
+	Source(string) Source
 }
 
 // Handler is a prototype of a function that handles the event of a
@@ -97,6 +140,46 @@ type Context interface {
 // propagated to Parse method and returned.
 type Handler = func(Context) error
 
+// Validator is a prototype of a function that validates a Parameter value.
+// It receives the Go value the Parameter was parsed into, the count of a
+// counter or raw string values of a Parameter without a Go value and returns
+// a non-nil error if the value is invalid.
+type Validator = func(interface{}) error
+
+// Value is the interface to a Go value that converts a string argument to
+// itself. If a Go value registered with a Parameter implements Value, its'
+// Set method is used to convert arguments instead of default conversion.
+type Value interface {
+	// Set sets the value from a string or returns an error.
+	Set(string) error
+	// String returns the value as a string.
+	String() string
+}
+
+// Converter is a prototype of a function that converts a string argument to
+// a Go value of the type it was registered for with State.RegisterConverter.
+// Returned value must be assignable to that type.
+type Converter = func(string) (interface{}, error)
+
+// converters maps a Go type to its' Converter.
+type converters map[reflect.Type]Converter
+
+// convertValue converts s using f and sets the result to Go value pointed to
+// by i.
+func convertValue(f Converter, s string, i interface{}) error {
+	var value, err = f(s)
+	if err != nil {
+		return err
+	}
+	var v = reflect.ValueOf(value)
+	var target = reflect.ValueOf(i).Elem()
+	if !v.IsValid() || !v.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("converter returned %T, want %s", value, target.Type())
+	}
+	target.Set(v)
+	return nil
+}
+
 // context is the Context adapter. It wraps a Command and returns its'
 // properties and the properties of its' Parameters in a single type that directly
 // implements Context interface.
@@ -132,12 +215,84 @@ func (c *context) Value(name string) string {
 	return ""
 }
 
+// Values implements Context.Values.
+func (c *context) Values(name string) []string {
+	var param *Parameter
+	var exists bool
+	if param, exists = c.cmd.Parameters.longparams[name]; exists {
+		return param.rawvalues
+	}
+	return []string{}
+}
+
+// Default implements Context.Default.
+func (c *context) Default(name string) string {
+	var param *Parameter
+	var exists bool
+	if param, exists = c.cmd.Parameters.longparams[name]; exists {
+		return param.defstring
+	}
+	return ""
+}
+
+// Get implements Context.Get.
+func (c *context) Get(name string) interface{} {
+	var param *Parameter
+	var exists bool
+	if param, exists = c.cmd.Parameters.longparams[name]; exists {
+		return param.goValue()
+	}
+	return nil
+}
+
+// Source implements Context.Source.
+func (c *context) Source(name string) Source {
+	var param *Parameter
+	var exists bool
+	if param, exists = c.cmd.Parameters.longparams[name]; exists {
+		return param.source
+	}
+	return SourceNone
+}
+
+// Count implements Context.Count.
+func (c *context) Count(name string) int {
+	var param *Parameter
+	var exists bool
+	if param, exists = c.cmd.Parameters.longparams[name]; exists {
+		return param.count
+	}
+	return 0
+}
+
 // Args implements Context.Args.
 func (c *context) Arguments() []string { return c.arguments }
 
 // Print implements Context.Print.
 func (c *context) Print() string { return c.cmd.Print() }
 
+// validate runs validators of context's command parsed Parameters in order
+// of registration, then the command validator.
+func (c *context) validate() error {
+	var param *Parameter
+	var err error
+	for _, long := range c.cmd.Parameters.longindexes {
+		if param = c.cmd.Parameters.longparams[long]; !param.parsed {
+			continue
+		}
+		if err = param.validate(); err != nil {
+			return fmt.Errorf("%w: parameter '%s': %v", ErrValidate, long, err)
+		}
+	}
+	if c.cmd.validator == nil {
+		return nil
+	}
+	if err = c.cmd.validator(c); err != nil {
+		return fmt.Errorf("%w: command '%s': %v", ErrValidate, c.cmd.name, err)
+	}
+	return nil
+}
+
 // exec executes the context's command and returns its' handler return value.
 func (c *context) exec() error {
 	if c.cmd.handler == nil {
@@ -162,6 +317,8 @@ const (
 	ShortArgument
 	// CombinedArgument represents a word argument directly preffixed with "-".
 	CombinedArgument
+	// TerminatorArgument represents the "--" end of options terminator.
+	TerminatorArgument
 )
 
 // String implements stringer on argKind.
@@ -179,6 +336,8 @@ func (ak Argument) String() (s string) {
 		s = "short parameter"
 	case CombinedArgument:
 		s = "combined short arguments"
+	case TerminatorArgument:
+		s = "end of options"
 	}
 	return
 }
@@ -219,11 +378,17 @@ func (ak Argument) String() (s string) {
 // registered in any order, but must be defined before any raw params.
 //
 // Short Param names have the "-" prefix, can be one character long and can be
-// combined together following the short form prefix if none of the combined
-// Parameters require a Param Value. All combined short params must all be optional.
+// combined together following the short form prefix. All but the last of the
+// combined short params must not take a value. The last one may take a value
+// from the rest of the argument or from the argument following, e.g.
+// "-xzfarchive.tgz" or "-xzf archive.tgz".
 //
 // Long Param names have the "--" prefix and cannot be combined.
 //
+// Values of prefixed params can be specified as the argument following the
+// param or attached to the param itself, e.g. "--name=value", "-n=value" or
+// "-nvalue" if the short param "n" takes a value.
+//
 // Raw params are not addressed but are instead matched against registered raw
 // Parameters in order of registration as they appear on command line, respectively.
 //
@@ -233,6 +398,12 @@ func (ak Argument) String() (s string) {
 // e.g. "rmdir -v /home/me/stuff" where "rmdir" is a command, "-v" is a
 // prefixed param and "/home/me/stuff" is a raw parameter.
 //
+// By default parsing is strict as in POSIX; once a raw param is parsed all
+// following arguments of the Command are treated as raw params regardless of
+// any prefixes. If Permute is true prefixed params are accepted anywhere among
+// raw params of a Command, up to a "--" terminator, as in GNU getopt.
+// e.g. "cp src dst --force".
+//
 // If Parameters are defined as optional they do not cause a parse error if not
 // parsed from program args and return a parse error if defined as required and
 // not parsed from command line.
@@ -247,11 +418,49 @@ func (ak Argument) String() (s string) {
 //
 // If no params were defined on a Command and the command has no CommandFunc
 // registered an error is returned.
+//
+// A "--" argument terminates options. All arguments following it are treated
+// as text arguments regardless of any prefixes and are passed to raw params
+// or to a raw Command handler unchanged.
 type State struct {
 	// arguments is a slice of arguments being parsed.
 	// Args are set once by Parse() then read and updated by Commands
 	// and Parameters down the Parse chain until exhausted or an error occurs.
 	arguments []string
+	// terminated is true if "--" end of options terminator was parsed.
+	terminated bool
+	// argcount is the number of arguments given to Parse.
+	argcount int
+	// Permute if true allows prefixed params to be specified after or
+	// between raw params of a Command. If false, parsing is POSIX strict.
+	Permute bool
+	// Abbreviate if true allows Command and long Parameter names to be
+	// abbreviated to any prefix unique among their siblings, e.g. "inst"
+	// for "install" or "--verb" for "--verbose". Exact names always match.
+	Abbreviate bool
+	// EnvPrefix if not empty enables environment variable fallback for all
+	// prefixed Parameters that do not declare environment variables
+	// explicitly using SetEnv. Variable name is derived from the prefix, the Command path and
+	// the Parameter long name, e.g. "MYAPP_SERVE_PORT".
+	EnvPrefix string
+	// LookupEnv is the function used to look up environment variables. If
+	// nil, os.LookupEnv is used.
+	LookupEnv func(key string) (string, bool)
+	// Interactive if true enables prompting for values of required
+	// Parameters not specified on command line, in environment or in
+	// configuration. Prompting is done once all arguments were parsed,
+	// before validation. See Parameters.SetSecret.
+	Interactive bool
+	// PromptReader is the reader prompt answers are read from. If nil,
+	// os.Stdin is used.
+	PromptReader io.Reader
+	// PromptWriter is the writer prompts are written to. If nil, os.Stdout
+	// is used.
+	PromptWriter io.Writer
+	// config holds Parameter values loaded from configuration files.
+	config configValues
+	// converters holds registered Converters.
+	converters converters
 	// matches is a slice of commands parsed from command line in the
 	// order as they were parsed.
 	matches []*Command
@@ -266,6 +475,21 @@ func NewState() *State {
 	return state
 }
 
+// RegisterConverter registers a Converter for Go values of type t. If a Go
+// value registered with a Parameter is of type t or is a slice of t, if the
+// Parameter is repeated, its' arguments are converted using f unless the Go
+// value implements Value. Registering a nil f removes the Converter.
+func (state *State) RegisterConverter(t reflect.Type, f Converter) {
+	if f == nil {
+		delete(state.converters, t)
+		return
+	}
+	if state.converters == nil {
+		state.converters = make(converters)
+	}
+	state.converters[t] = f
+}
+
 // Extra returns current arguments in State.
 func (p *State) Arguments() []string { return p.arguments }
 
@@ -276,7 +500,7 @@ func (state *State) ArgumentCount() int { return len(state.arguments) }
 // Returns output suitable for terminal display.
 func (state State) Print() string {
 	sb := &strings.Builder{}
-	printCommands(sb, state.Commands, 0)
+	printCommands(sb, state.Commands, 0, nil, state.EnvPrefix)
 	return sb.String()
 }
 
@@ -285,12 +509,23 @@ func (state State) Print() string {
 // it is returned. Returns ErrNoArgs if args are empty and there are defined
 // Commands or Parameters.
 //
+// Parsed values are written to Go values of Parameters only if parsing and
+// validation succeed, before any handlers are called. On error Go values
+// keep their previous values.
+//
 // TODO Remove.
 func (state *State) Parse(args []string) error {
 	state.reset()
 	state.arguments = args
-	var err = state.Commands.Parse(state)
-	if err == nil {
+	state.argcount = len(args)
+	var err error
+	if err = convertDefaults(state.Commands, state.converters); err != nil {
+		return err
+	}
+	if err = state.Commands.Parse(state); err == nil {
+		if err = state.parseRoot(); err != nil {
+			return err
+		}
 		return state.VisitMatches()
 	}
 	// There are unparsed arguments.
@@ -308,6 +543,9 @@ func (state *State) Parse(args []string) error {
 			// ...arguments are extra.
 			return ErrExtraArguments
 		}
+		if err = state.parseRoot(); err != nil {
+			return err
+		}
 		return state.VisitMatches()
 	}
 	// Pass through as top error only if nothing was matched. If it was,
@@ -331,15 +569,44 @@ func (p *State) Peek() string {
 // Next returns the first argument in Parser arguments trimmed of any prefixes
 // and its' kind. If the argument is malformed returns an empty arg
 // kind == argInvalid.
+//
+// If the argument is "--" kind is TerminatorArgument. Once the terminator is
+// consumed using Terminate all following arguments are returned unchanged
+// as TextArgument.
+//
+// If a long argument has a value attached with "=", as in "--name=value", or a
+// short argument has a value attached with "=", as in "-n=value", the returned
+// arg is just the parameter name. Attached value can be retrieved using
+// Attached.
 func (p *State) Next() (arg string, kind Argument) {
+	arg, kind, _, _ = p.next()
+	return
+}
+
+// Attached returns a value attached to the first argument in Parser arguments
+// and truth if the argument has a value attached. See Next for details.
+func (p *State) Attached() (value string, attached bool) {
+	_, _, value, attached = p.next()
+	return
+}
+
+// next is the implementation of Next and Attached.
+func (p *State) next() (arg string, kind Argument, value string, attached bool) {
 	kind = NoArgument
 	if len(p.arguments) == 0 {
 		return
 	}
 	arg = p.arguments[0]
+	if p.terminated {
+		kind = TextArgument
+		return
+	}
 	if len(arg) == 0 {
 		return
 	}
+	if arg == "--" {
+		return "", TerminatorArgument, "", false
+	}
 	for i, l := 0, len(arg); i < l; i++ {
 		if arg[0] != '-' {
 			break
@@ -354,22 +621,43 @@ func (p *State) Next() (arg string, kind Argument) {
 			continue
 		}
 		if kind == LongArgument {
-			return "", InvalidArgument
+			return "", InvalidArgument, "", false
+		}
+	}
+	switch kind {
+	case LongArgument:
+		if i := strings.IndexByte(arg, '='); i >= 0 {
+			arg, value, attached = arg[:i], arg[i+1:], true
+		}
+	case ShortArgument:
+		if len(arg) > 1 && arg[1] == '=' {
+			arg, value, attached = arg[:1], arg[2:], true
 		}
 	}
 	if len(arg) == 0 {
-		return "", InvalidArgument
+		return "", InvalidArgument, "", false
 	}
 	if kind == NoArgument {
 		kind = TextArgument
 		return
 	}
 	if kind == ShortArgument && len(arg) > 1 {
-		return arg, CombinedArgument
+		return arg, CombinedArgument, "", false
 	}
 	return
 }
 
+// Terminate discards the "--" terminator argument and marks the State as
+// terminated so that all following arguments are treated as text arguments.
+// Returns a bool indicating if there is any args left.
+func (p *State) Terminate() bool {
+	p.terminated = true
+	return p.Skip()
+}
+
+// Terminated returns true if "--" end of options terminator was parsed.
+func (p *State) Terminated() bool { return p.terminated }
+
 // Skip discards the first arg in the args slice and returns a bool indicating
 // if there is any args left.
 func (p *State) Skip() bool {
@@ -387,16 +675,37 @@ func (p *State) AddMatch(command *Command) {
 
 // VisitMatches visits all matched commands, constructs a context and calls
 // their handlers. Propagates first non-nil return value of visited handler.
+//
+// Before any handler is called, if State is Interactive, missing required
+// Parameters of matched commands are prompted for and checked, then
+// validators of all matched commands and their parsed Parameters are run. If any fails ErrValidate or a descendant is
+// returned, no handlers are called and Go values of Parameters are not
+// modified. Otherwise parsed and default values are written to Go values
+// of all Parameters before handlers are called.
 func (p *State) VisitMatches() error {
 	var l = len(p.matches)
-	if l < 1 {
-		return nil
-	}
 	var ctx = context{
 		arguments: p.arguments,
 	}
 	var i int
 	var err error
+	for i = 0; i < l; i++ {
+		ctx.cmd = p.matches[i]
+		ctx.executed = i == l-1
+		if p.Interactive {
+			ctx.cmd.Parameters.prompt(p)
+			if err = ctx.cmd.Parameters.check(); err != nil {
+				return err
+			}
+		}
+		if err = ctx.validate(); err != nil {
+			return err
+		}
+	}
+	commitCommands(p.Commands)
+	if l < 1 {
+		return nil
+	}
 	for i = 0; i < l-1; i++ {
 		ctx.cmd = p.matches[i]
 		ctx.executed = false
@@ -409,6 +718,55 @@ func (p *State) VisitMatches() error {
 	return ctx.exec()
 }
 
+// path returns names of matched commands followed by the name of cmd,
+// omitting empty names.
+func (p *State) path(cmd *Command) (path []string) {
+	for _, match := range p.matches {
+		path = appendPath(path, match.name)
+	}
+	return appendPath(path, cmd.name)
+}
+
+// parseRoot sets values of Parameters of the empty Command from environment
+// and configuration if the empty Command was not matched from command line.
+// If any were set the empty Command is checked and validated as if matched.
+func (p *State) parseRoot() error {
+	var cmd, ok = p.commandmap[""]
+	if !ok {
+		return nil
+	}
+	for _, match := range p.matches {
+		if match == cmd {
+			return nil
+		}
+	}
+	var err error
+	if err = cmd.Parameters.parseEnv(p, nil); err != nil {
+		return err
+	}
+	if err = cmd.Parameters.parseConfig(p); err != nil {
+		return err
+	}
+	if !cmd.Parameters.anyParsed() {
+		return nil
+	}
+	if p.Interactive {
+		cmd.Parameters.prompt(p)
+	}
+	if err = cmd.Parameters.check(); err != nil {
+		return err
+	}
+	var ctx = context{
+		cmd:       cmd,
+		arguments: p.arguments,
+	}
+	return ctx.validate()
+}
+
+// position returns the index of the current argument in arguments given to
+// Parse.
+func (p *State) position() int { return p.argcount - len(p.arguments) }
+
 // lastMatch help.
 func (p *State) lastMatch() *Command {
 	if len(p.matches) == 0 {
@@ -420,16 +778,19 @@ func (p *State) lastMatch() *Command {
 // reset resets states of Parser, Commands and their params.
 func (state *State) reset() {
 	state.matches = []*Command{}
+	state.terminated = false
 	resetCommands(state.Commands)
 }
 
 // Command is a command definition.
 type Command struct {
-	help        string  // help is the help text.
-	handler     Handler // handler is the command handler. Can be nil.
-	raw         bool
-	*Parameters // Parameters are this Command's Parameters.
-	*Commands   // Commands are this Command's sub Commands.
+	help        string   // help is the help text.
+	handler     Handler  // handler is the command handler. Can be nil.
+	raw         bool     // raw specifies if command accepts raw arguments.
+	aliases     []string // aliases are alternative command names.
+	validator   Handler  // validator is the command validator. Can be nil.
+	*Parameters          // Parameters are this Command's Parameters.
+	*Commands            // Commands are this Command's sub Commands.
 }
 
 // NewCommand returns a new Command instance with specified optional help and
@@ -456,6 +817,26 @@ func (c *Command) Handler() Handler { return c.handler }
 // Raw help.
 func (c *Command) Raw() bool { return c.raw }
 
+// SetHandler sets the Command handler. A nil handler removes it.
+// Returns the Command.
+func (c *Command) SetHandler(handler Handler) *Command {
+	c.handler = handler
+	return c
+}
+
+// Validator returns the Command validator or nil if none.
+func (c *Command) Validator() Handler { return c.validator }
+
+// SetValidator sets the Command validator. It is called with Command's
+// Context after parsing and before any handlers of matched Commands are
+// called. If it returns a non-nil error no handlers are called and the
+// error is returned by Parse as ErrValidate. A nil validator removes it.
+// Returns the Command.
+func (c *Command) SetValidator(validator Handler) *Command {
+	c.validator = validator
+	return c
+}
+
 // nameToCommand is a map of command name to *Command.
 type nameToCommand map[string]*Command
 
@@ -483,12 +864,15 @@ func NewCommands(parent *Command) *Commands {
 }
 
 // CommandCount returns number of registered commands.
-func (c *Commands) CommandCount() int { return len(c.commandmap) }
+func (c *Commands) CommandCount() int { return len(c.nameindexes) }
+
+// CommandNames returns names of registered Commands in order of registration.
+func (c *Commands) CommandNames() []string { return append([]string{}, c.nameindexes...) }
 
 // Print prints Commands as a structured text suitable for terminal display.
 func (c *Commands) Print() string {
 	var sb = &strings.Builder{}
-	printCommands(sb, c, 0)
+	printCommands(sb, c, 0, nil, "")
 	return sb.String()
 }
 
@@ -554,6 +938,43 @@ func (c *Commands) MustGetCommand(name string) *Command {
 	panic(fmt.Sprintf("commandline: command '%s' not found", name))
 }
 
+// AliasCommand registers one or more aliases for a Command registered under
+// specified name. Aliases must be unique among names and aliases of Commands
+// and resolve to the same Command. Context.Name returns the Command name
+// regardless of the name the Command was invoked with. If an error occurs it
+// is ErrRegister or a descendant and no aliases are registered.
+func (c *Commands) AliasCommand(name string, aliases ...string) error {
+	var cmd, ok = c.commandmap[name]
+	if !ok || name == "" {
+		return fmt.Errorf("%w: command '%s' not found", ErrRegister, name)
+	}
+	var alias string
+	var set = make(map[string]bool)
+	for _, alias = range aliases {
+		if alias == "" {
+			return fmt.Errorf("%w: invalid alias", ErrRegister)
+		}
+		if _, ok = c.commandmap[alias]; ok || set[alias] {
+			return fmt.Errorf("%w: command: '%s'", ErrDuplicate, alias)
+		}
+		set[alias] = true
+	}
+	for _, alias = range aliases {
+		c.commandmap[alias] = cmd
+	}
+	cmd.aliases = append(cmd.aliases, aliases...)
+	return nil
+}
+
+// MustAliasCommand is like AliasCommand but panics on error.
+// Returns the aliased *Command.
+func (c *Commands) MustAliasCommand(name string, aliases ...string) *Command {
+	if err := c.AliasCommand(name, aliases...); err != nil {
+		panic(err)
+	}
+	return c.commandmap[name]
+}
+
 // Parse parses Parser args into this Commands.
 func (c *Commands) Parse(state *State) error {
 	var err error
@@ -567,9 +988,28 @@ func (c *Commands) Parse(state *State) error {
 	case NoArgument:
 		return ErrNoArguments
 	case TextArgument:
-		if cmd, ok = c.commandmap[arg]; !ok {
+		// Arguments following a terminator are never Commands.
+		if state.Terminated() {
 			return fmt.Errorf("%w: %s", ErrNotFound, arg)
 		}
+		if cmd, err = c.find(state, arg); err != nil {
+			return err
+		}
+	case TerminatorArgument:
+		// Following arguments are raw params of an empty command, if it
+		// has any. It consumes the terminator when parsing Parameters.
+		if cmd, ok = c.commandmap[""]; ok {
+			if param := cmd.Parameters.last(); param != nil && param.raw {
+				global = true
+				break
+			}
+		}
+		// Consume the terminator and leave following arguments as
+		// unparsed arguments to the last matched command.
+		if !state.Terminate() {
+			return ErrNoArguments
+		}
+		return fmt.Errorf("%w: %s", ErrNotFound, state.Peek())
 	default:
 		if cmd, ok = c.commandmap[""]; !ok {
 			return fmt.Errorf("%w: %s", ErrNotFound, arg)
@@ -616,6 +1056,40 @@ func (c *Commands) Parse(state *State) error {
 	return err
 }
 
+// find returns a Command by name or if State allows abbreviations, a
+// Command whose name is uniquely prefixed by name. Returns ErrNotFound if not
+// found or ErrAmbiguous if the abbreviation matches multiple Commands.
+func (c *Commands) find(state *State, name string) (*Command, error) {
+	var cmd, ok = c.commandmap[name]
+	if ok {
+		return cmd, nil
+	}
+	if !state.Abbreviate || name == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	var candidates []string
+	var key string
+	var match *Command
+	for key, cmd = range c.commandmap {
+		if !strings.HasPrefix(key, name) {
+			continue
+		}
+		candidates = append(candidates, key)
+		if match != nil && match != cmd {
+			ok = true
+		}
+		match = cmd
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if ok {
+		sort.Strings(candidates)
+		return nil, fmt.Errorf("%w: command '%s' matches: %s", ErrAmbiguous, name, strings.Join(candidates, ", "))
+	}
+	return match, nil
+}
+
 // addCommand registers a new command under specified name and help with
 // specified handler and marks it raw if raw if true. If an error occurs it will
 // be ErrRegister or a descendant and command will be nil.
@@ -650,12 +1124,29 @@ func (c *Commands) addCommand(name, help string, handler Handler, raw bool) (*Co
 	return cmd, nil
 }
 
+// removeCommand removes a command registered under name, if found.
+func (c *Commands) removeCommand(name string) {
+	delete(c.commandmap, name)
+	for i, n := range c.nameindexes {
+		if n == name {
+			c.nameindexes = append(c.nameindexes[:i], c.nameindexes[i+1:]...)
+			break
+		}
+	}
+}
+
 // Parameter defines a Command parameter contained in a Parameters.
 type Parameter struct {
+	// name is the Param long name.
+	name string
+	// aliases are alternative Param long names.
+	aliases []string
 	// help is the Param help text.
 	help string
 	// rawvalue is the raw parsed param value, possibly empty.
 	rawvalue string
+	// rawvalues are all raw parsed param values of a repeated param.
+	rawvalues []string
 	// value is a pointer to a Go value which is set
 	// from parsed Param value if not nil and points to a
 	// valid target.
@@ -664,6 +1155,47 @@ type Parameter struct {
 	raw bool
 	// required specifies if this Param is required.
 	required bool
+	// repeated specifies if this Param can be specified multiple times.
+	repeated bool
+	// counted specifies if this Param is a counter.
+	counted bool
+	// count is the number of times a counter Param was parsed.
+	count int
+	// negatable specifies if this Param is a boolean that can be negated.
+	negatable bool
+	// flag specifies if this Param is a boolean that takes no value and is
+	// true if parsed.
+	flag bool
+	// variadic specifies if this Param is a raw param that takes all
+	// remaining raw arguments.
+	variadic bool
+	// min and max are the minimum and maximum number of arguments a variadic
+	// Param takes. Max of 0 means no limit.
+	min, max int
+	// defvalue is a copy of the default Go value, invalid if none.
+	defvalue reflect.Value
+	// defstring is the default value as displayed, empty if none.
+	defstring string
+	// defconvert specifies if defstring is converted to defvalue on each
+	// parse.
+	defconvert bool
+	// env are names of environment variables to read the value from.
+	env []string
+	// choices are allowed raw values, any if empty.
+	choices []string
+	// validators validate the parsed value.
+	validators []Validator
+	// staged is a pointer to a Go value of the same type as value holding
+	// the value being parsed, invalid until first used after reset.
+	staged reflect.Value
+	// source is the source of the Param value.
+	source Source
+	// position is the index of the argument in command line arguments the
+	// Param was last specified in or -1 if not parsed from command line.
+	position int
+	// secret specifies if the Param value is read without echo when
+	// prompted.
+	secret bool
 	// parsed indicates if Param was parsed from arguments.
 	parsed bool
 }
@@ -678,95 +1210,385 @@ func NewParameter(help string, required, raw bool, value interface{}) *Parameter
 	}
 }
 
-// nameToParameter maps a param name to *Param.
-type nameToParameter map[string]*Parameter
+// Name returns the Param long name.
+func (p *Parameter) Name() string { return p.name }
 
-// longToShort maps a long param name to short param name.
-type longToShort map[string]string
+// Help returns the Param help.
+func (p *Parameter) Help() string { return p.help }
 
-// A Parameters defines a set of Command Parameters unique by long name.
-type Parameters struct {
-	// cmd is the reference to owner *Command.
-	cmd *Command
-	// longparams is a map of long param name to *Param.
-	longparams nameToParameter
-	// shortparams is a map of short param name to *Param.
-	shortparams nameToParameter
-	// longtoshort maps a long param name to short param name.
-	longtoshort longToShort
-	// longindexes hold long param names in order as they are added.
-	longindexes []string
-}
+// Value returns the pointer to the Go value of the Param or nil if none.
+func (p *Parameter) Value() interface{} { return p.value }
 
-// newParameters returns a new instance of *Parameters.
-func newParameters(cmd *Command) *Parameters {
-	return &Parameters{
-		cmd,
-		make(nameToParameter),
-		make(nameToParameter),
-		make(longToShort),
-		[]string{},
+// Raw returns true if the Param is a raw Param.
+func (p *Parameter) Raw() bool { return p.raw }
+
+// Repeated returns true if the Param can be specified multiple times.
+func (p *Parameter) Repeated() bool { return p.repeated }
+
+// Counter returns true if the Param is a counter.
+func (p *Parameter) Counter() bool { return p.counted }
+
+// Negatable returns true if the Param is a negatable boolean.
+func (p *Parameter) Negatable() bool { return p.negatable }
+
+// reset resets the Param parse state and discards the staged value.
+func (p *Parameter) reset() {
+	p.parsed = false
+	p.rawvalue = ""
+	p.rawvalues = nil
+	p.count = 0
+	p.staged = reflect.Value{}
+	p.source, p.position = SourceNone, -1
+	if p.value != nil || p.defstring != "" {
+		p.source = SourceDefault
 	}
 }
 
-// ParameterCount returns number of defined parameters.
-func (p *Parameters) ParameterCount() int { return len(p.longindexes) }
+// target returns a pointer to the staged Go value which arguments are
+// converted to. Staged value is initialized to the default value, if any,
+// or to a copy of the Go value on first use after reset.
+func (p *Parameter) target() interface{} {
+	if !p.staged.IsValid() {
+		var v = reflect.Indirect(reflect.ValueOf(p.value))
+		p.staged = reflect.New(v.Type())
+		if p.defvalue.IsValid() {
+			v = p.defvalue
+		}
+		p.staged.Elem().Set(copyValue(v))
+	}
+	return p.staged.Interface()
+}
 
-// AddParam registers a new prefixed Param in these Parameters.
-//
-// Long param name is required, short is optional and can be empty, as is help.
-//
-// If required is specified value must be a pointer to a supported Go value
-// which will be updated to a value parsed from an argument following param.
-// If a required Param or its' value is not found in command line args an error
-// is returned.
-//
-// If Param is not marked as required, specifying a value parameter is optional
-// but dictates that:
-// If nil, a value for the Param will not be parsed from args.
-// If valid, the parser will parse the argument following the Param into it.
-//
-// Registration order is important. Prefixed params must be registered before
-// raw params and are printed in order of registration.
-//
-// If an error occurs Param is not registered.
-func (p *Parameters) AddParam(long, short, help string, required bool, value interface{}) error {
-	return p.addParam(long, short, help, required, false, value)
+// commit writes the staged value to the Go value, if any.
+func (p *Parameter) commit() {
+	if p.value == nil {
+		return
+	}
+	reflect.Indirect(reflect.ValueOf(p.value)).Set(reflect.ValueOf(p.target()).Elem())
 }
 
-// MustAddParam is like AddParam except the function panics on error.
-// Returns a Command that the param was added to.
-func (p *Parameters) MustAddParam(long, short, help string, required bool, value interface{}) *Command {
-	var err error
-	if err = p.AddParam(long, short, help, required, value); err != nil {
-		panic(err)
+// setDefault sets the default value from a Go value v which is copied. If s
+// is empty the default value is displayed as v formatted, if not zero.
+func (p *Parameter) setDefault(v reflect.Value, s string) {
+	p.defvalue = copyValue(v)
+	if s == "" && !v.IsZero() {
+		s = fmt.Sprint(v.Interface())
 	}
-	return p.cmd
+	p.defstring = s
+	p.defconvert = false
 }
 
-// AddRawParam registers a raw Param under specified name which must be unique
-// in long Parameters names. Raw params can only be defined after prefixed
-// params or other raw params. Calls to AddParam after AddRawParam will error.
-//
-// Registering a raw parameter for a command will disable command's ability to
-// have sub commands registered as its invocation would be ambiguous with raw
-// parameters during Parameters parsing. If the command already has sub commands
-// registered the function will error.
-//
-// Parsed arguments are applied to registered raw Parameters in order as they are
-// defined. If value is a pointer to a valid Go value argument will be converted
-// to that Go value. Specifying a value is optional and if nil, parsed argument
-// will not be parsed into the value.
-//
-// Marking a raw param as required does not imply that value must not be nil
-// as is in prefixed params. Required flag solely returns a parse error if
-// required raw param was not parsed and value is set only if non-nil.
-//
-// A single non-required raw Param is allowed and it must be the last one.
-//
-// If an error occurs it is returned and the Param is not registered.
-func (p *Parameters) AddRawParam(name, help string, required bool, value interface{}) error {
-	return p.addParam(name, "", help, required, true, value)
+// convertDefault converts defstring to defvalue using conv. On error
+// defvalue is unmodified.
+func (p *Parameter) convertDefault(conv converters) error {
+	var v = reflect.New(reflect.Indirect(reflect.ValueOf(p.value)).Type())
+	if err := stringToGoValue(p.defstring, v.Interface(), conv); err != nil {
+		return err
+	}
+	p.defvalue = v.Elem()
+	return nil
+}
+
+// envNames returns names of environment variables the Param is read from.
+// If none were set and prefix is not empty a name is derived from prefix,
+// path and Param name. Raw params are not read from environment.
+func (p *Parameter) envNames(prefix string, path []string) []string {
+	if p.raw {
+		return nil
+	}
+	if len(p.env) > 0 || prefix == "" {
+		return p.env
+	}
+	var name = strings.Join(append(append([]string{prefix}, path...), p.name), "_")
+	name = strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	return []string{strings.ToUpper(name)}
+}
+
+// apply sets the Param value from values specified outside of command line
+// arguments, e.g. environment, and marks it as parsed. Counter takes a
+// count, flags and negatable params a bool and repeated params any number
+// of values. Other params take exactly one value.
+func (p *Parameter) apply(values []string, conv converters) (err error) {
+	var value string
+	if !p.multiple() || p.counted {
+		if len(values) != 1 {
+			return fmt.Errorf("%w: parameter '%s' takes a single value", ErrParse, p.name)
+		}
+		value = values[0]
+	}
+	switch {
+	case p.counted:
+		var n int
+		if n, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%w: error converting value %s: %v", ErrConvert, value, err)
+		}
+		p.count = n - 1
+		return p.set(value, conv)
+	case p.negatable || p.flag || (p.value == nil && !p.raw):
+		var b bool
+		if b, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%w: error converting value %s: %v", ErrConvert, value, err)
+		}
+		if p.negatable || p.flag {
+			return p.set(strconv.FormatBool(b), conv)
+		}
+		if b {
+			return p.set(p.name, conv)
+		}
+		return nil
+	case p.multiple():
+		for _, value = range values {
+			if err = p.set(value, conv); err != nil {
+				return
+			}
+		}
+		return nil
+	}
+	return p.set(value, conv)
+}
+
+// set sets the param value from a raw argument and marks it as parsed.
+// If Param has choices arg must be one of them. If Param has a Go value arg
+// is converted to it. If Param is repeated arg is appended to Go value slice
+// which is cleared on first occurrence.
+func (p *Parameter) set(arg string, conv converters) (err error) {
+	if err = p.checkChoice(arg); err != nil {
+		return
+	}
+	if p.counted {
+		p.count++
+		if p.value != nil {
+			*p.target().(*int) = p.count
+		}
+		p.rawvalue = strconv.Itoa(p.count)
+		p.rawvalues = append(p.rawvalues, arg)
+		p.parsed = true
+		return nil
+	}
+	if p.value != nil {
+		if p.repeated {
+			if !p.parsed {
+				var v = reflect.Indirect(reflect.ValueOf(p.target()))
+				v.Set(reflect.MakeSlice(v.Type(), 0, 1))
+			}
+			err = appendGoValue(arg, p.target(), conv)
+		} else {
+			err = stringToGoValue(arg, p.target(), conv)
+		}
+		if err != nil {
+			return
+		}
+	}
+	p.rawvalue = arg
+	p.rawvalues = append(p.rawvalues, arg)
+	p.parsed = true
+	return nil
+}
+
+// checkChoice returns nil if Param has no choices or arg is one of them,
+// ErrInvalidChoice listing the choices and the closest one otherwise.
+func (p *Parameter) checkChoice(arg string) error {
+	if len(p.choices) == 0 {
+		return nil
+	}
+	var choice, closest string
+	var dist, min = 0, -1
+	for _, choice = range p.choices {
+		if choice == arg {
+			return nil
+		}
+		if dist = distance(arg, choice); min < 0 || dist < min {
+			closest, min = choice, dist
+		}
+	}
+	var err = fmt.Errorf("%w: parameter '%s' value '%s', valid choices: %s", ErrInvalidChoice, p.name, arg, strings.Join(p.choices, ", "))
+	// Suggest the closest choice if it is close enough.
+	if min <= 1 || min <= len(closest)/3 {
+		err = fmt.Errorf("%w; did you mean '%s'?", err, closest)
+	}
+	return err
+}
+
+// checkDefault returns ErrRegister if Param has a default value that is not
+// one of its' choices. Each value of a repeated Param default is checked.
+func (p *Parameter) checkDefault() error {
+	if len(p.choices) == 0 || p.defstring == "" {
+		return nil
+	}
+	var values = []string{p.defstring}
+	if p.repeated && p.defvalue.IsValid() && p.defvalue.Kind() == reflect.Slice {
+		values = values[:0]
+		for i := 0; i < p.defvalue.Len(); i++ {
+			values = append(values, fmt.Sprint(p.defvalue.Index(i).Interface()))
+		}
+	}
+	for _, value := range values {
+		if p.checkChoice(value) != nil {
+			return fmt.Errorf("%w: parameter '%s' default value '%s' is not one of choices: %s", ErrRegister, p.name, value, strings.Join(p.choices, ", "))
+		}
+	}
+	return nil
+}
+
+// mark marks the Param value as coming from source at position in command
+// line arguments.
+func (p *Parameter) mark(source Source, position int) {
+	p.source, p.position = source, position
+}
+
+// validate runs Param validators in order and returns the first error.
+func (p *Parameter) validate() error {
+	for _, validator := range p.validators {
+		if err := validator(p.goValue()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goValue returns the parsed Go value, the count of a counter, raw values
+// of a repeated Param or the raw value if Param has no Go value.
+func (p *Parameter) goValue() interface{} {
+	switch {
+	case p.value != nil:
+		return reflect.Indirect(reflect.ValueOf(p.target())).Interface()
+	case p.counted:
+		return p.count
+	case p.repeated:
+		return p.rawvalues
+	}
+	return p.rawvalue
+}
+
+// multiple returns true if Param can be specified multiple times.
+func (p *Parameter) multiple() bool { return p.repeated || p.counted }
+
+// full returns true if Param is variadic and takes no more arguments.
+func (p *Parameter) full() bool {
+	return p.variadic && p.max > 0 && len(p.rawvalues) >= p.max
+}
+
+// takesValue returns true if Param takes a value argument.
+func (p *Parameter) takesValue() bool {
+	return p.value != nil && !p.counted && !p.negatable && !p.flag
+}
+
+// negated returns true if long is a negated name of a negatable Param.
+func (p *Parameter) negated(long string) bool {
+	if !p.negatable || long == p.name {
+		return false
+	}
+	for _, alias := range p.aliases {
+		if long == alias {
+			return false
+		}
+	}
+	return true
+}
+
+// nameToParameter maps a param name to *Param.
+type nameToParameter map[string]*Parameter
+
+// longToShort maps a long param name to short param name.
+type longToShort map[string]string
+
+// A Parameters defines a set of Command Parameters unique by long name.
+type Parameters struct {
+	// cmd is the reference to owner *Command.
+	cmd *Command
+	// longparams is a map of long param name to *Param.
+	longparams nameToParameter
+	// shortparams is a map of short param name to *Param.
+	shortparams nameToParameter
+	// longtoshort maps a long param name to short param name.
+	longtoshort longToShort
+	// longindexes hold long param names in order as they are added.
+	longindexes []string
+	// constraints are relationships between params checked after parsing.
+	constraints []constraint
+}
+
+// newParameters returns a new instance of *Parameters.
+func newParameters(cmd *Command) *Parameters {
+	return &Parameters{
+		cmd,
+		make(nameToParameter),
+		make(nameToParameter),
+		make(longToShort),
+		[]string{},
+		nil,
+	}
+}
+
+// ParameterCount returns number of defined parameters.
+func (p *Parameters) ParameterCount() int { return len(p.longindexes) }
+
+// ParameterNames returns long names of registered parameters in order of
+// registration.
+func (p *Parameters) ParameterNames() []string { return append([]string{}, p.longindexes...) }
+
+// GetParameter returns a *Parameter by long name or alias if found and truth
+// if found.
+func (p *Parameters) GetParameter(long string) (param *Parameter, ok bool) {
+	param, ok = p.longparams[long]
+	return
+}
+
+// AddParam registers a new prefixed Param in these Parameters.
+//
+// Long param name is required, short is optional and can be empty, as is help.
+//
+// If required is specified value must be a pointer to a supported Go value
+// which will be updated to a value parsed from an argument following param.
+// If a required Param or its' value is not found in command line args an error
+// is returned.
+//
+// If Param is not marked as required, specifying a value parameter is optional
+// but dictates that:
+// If nil, a value for the Param will not be parsed from args.
+// If valid, the parser will parse the argument following the Param into it.
+//
+// Registration order is important. Prefixed params must be registered before
+// raw params and are printed in order of registration.
+//
+// If an error occurs Param is not registered.
+func (p *Parameters) AddParam(long, short, help string, required bool, value interface{}) error {
+	return p.addParam(long, short, NewParameter(help, required, false, value))
+}
+
+// MustAddParam is like AddParam except the function panics on error.
+// Returns a Command that the param was added to.
+func (p *Parameters) MustAddParam(long, short, help string, required bool, value interface{}) *Command {
+	var err error
+	if err = p.AddParam(long, short, help, required, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddRawParam registers a raw Param under specified name which must be unique
+// in long Parameters names. Raw params can only be defined after prefixed
+// params or other raw params. Calls to AddParam after AddRawParam will error.
+//
+// Registering a raw parameter for a command will disable command's ability to
+// have sub commands registered as its invocation would be ambiguous with raw
+// parameters during Parameters parsing. If the command already has sub commands
+// registered the function will error.
+//
+// Parsed arguments are applied to registered raw Parameters in order as they are
+// defined. If value is a pointer to a valid Go value argument will be converted
+// to that Go value. Specifying a value is optional and if nil, parsed argument
+// will not be parsed into the value.
+//
+// Marking a raw param as required does not imply that value must not be nil
+// as is in prefixed params. Required flag solely returns a parse error if
+// required raw param was not parsed and value is set only if non-nil.
+//
+// A single non-required raw Param is allowed and it must be the last one.
+//
+// If an error occurs it is returned and the Param is not registered.
+func (p *Parameters) AddRawParam(name, help string, required bool, value interface{}) error {
+	return p.addParam(name, "", NewParameter(help, required, true, value))
 }
 
 // MustAddRawParam is like AddRawParam except the function panics on error.
@@ -779,6 +1601,374 @@ func (p *Parameters) MustAddRawParam(name, help string, required bool, value int
 	return p.cmd
 }
 
+// AliasParam registers one or more alternative long names for a Param
+// registered under specified long name. Aliases must be unique among long
+// names and aliases of Parameters and resolve to the same Param which is
+// still addressed by its' long name from Context. If the Param is negatable
+// negated forms of aliases are registered as well. If an error occurs it is
+// ErrRegister or a descendant and no aliases are registered.
+func (p *Parameters) AliasParam(long string, aliases ...string) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	var names []string
+	var alias string
+	for _, alias = range aliases {
+		if alias == "" {
+			return fmt.Errorf("%w: invalid alias", ErrRegister)
+		}
+		names = append(names, alias)
+		if param.negatable {
+			names = append(names, "no-"+alias)
+		}
+	}
+	var set = make(map[string]bool)
+	for _, alias = range names {
+		if _, ok = p.longparams[alias]; ok || set[alias] {
+			return fmt.Errorf("%w: long parameter name '%s'", ErrDuplicate, alias)
+		}
+		set[alias] = true
+	}
+	for _, alias = range names {
+		p.longparams[alias] = param
+	}
+	param.aliases = append(param.aliases, aliases...)
+	return nil
+}
+
+// MustAliasParam is like AliasParam except the function panics on error.
+// Returns a Command that the param aliases were added to.
+func (p *Parameters) MustAliasParam(long string, aliases ...string) *Command {
+	var err error
+	if err = p.AliasParam(long, aliases...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// SetRequired sets if a prefixed Param registered under specified long name
+// is required. It allows counters, negatable params and flags to be required
+// which are registered as optional. A required Param needs a Go value. If an
+// error occurs it is ErrRegister or a descendant.
+func (p *Parameters) SetRequired(long string, required bool) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	if param.raw {
+		return fmt.Errorf("%w: parameter '%s' is a raw parameter", ErrRegister, long)
+	}
+	if required && param.value == nil {
+		return fmt.Errorf("%w: value required", ErrRegister)
+	}
+	param.required = required
+	return nil
+}
+
+// MustSetRequired is like SetRequired except the function panics on error.
+// Returns a Command that the param was set required on.
+func (p *Parameters) MustSetRequired(long string, required bool) *Command {
+	var err error
+	if err = p.SetRequired(long, required); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// SetDefault sets the default value of a Param registered under specified
+// long name. Value is converted to the Param Go value type, if any, and is
+// written to the Go value on each parse before any arguments are parsed.
+// Default value is printed and can be retrieved using Context.Default.
+//
+// When a Param with a Go value is registered the current Go value is the
+// default value. SetDefault overrides it. Value is converted on each parse
+// as if given on command line, including Converters registered with State,
+// and Parse fails with ErrConvert or a descendant if it cannot be converted.
+// If the Param has choices the default value must be one of them.
+//
+// If an error occurs it is ErrRegister or a descendant.
+func (p *Parameters) SetDefault(long, value string) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	var defvalue, defstring, defconvert = param.defvalue, param.defstring, param.defconvert
+	param.defstring = value
+	if param.value != nil {
+		// Convert without Converters so the default value is available
+		// before parsing, if possible.
+		param.defvalue, param.defconvert = reflect.Value{}, true
+		param.convertDefault(nil)
+	}
+	if err := param.checkDefault(); err != nil {
+		param.defvalue, param.defstring, param.defconvert = defvalue, defstring, defconvert
+		return err
+	}
+	return nil
+}
+
+// MustSetDefault is like SetDefault except the function panics on error.
+// Returns a Command that the param default was set on.
+func (p *Parameters) MustSetDefault(long, value string) *Command {
+	var err error
+	if err = p.SetDefault(long, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// SetEnv sets names of environment variables to read the value of a Param
+// registered under specified long name from if the Param was not parsed from
+// command line arguments. First defined variable is used. Value from
+// environment is converted as if given on command line and satisfies the
+// required rule. Flags and negatable params take a boolean value and
+// counters a count. Params of the empty Command are read from environment
+// even if it was not matched from command line and if any are read they are
+// checked and validated as if it was matched. Raw params cannot be read from
+// environment, as they cannot be configured. If an error occurs it is
+// ErrRegister or a descendant.
+func (p *Parameters) SetEnv(long string, names ...string) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	if param.raw {
+		return fmt.Errorf("%w: parameter '%s' is a raw parameter", ErrRegister, long)
+	}
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("%w: invalid environment variable name", ErrRegister)
+		}
+	}
+	param.env = names
+	return nil
+}
+
+// MustSetEnv is like SetEnv except the function panics on error.
+// Returns a Command that the param environment variables were set on.
+func (p *Parameters) MustSetEnv(long string, names ...string) *Command {
+	var err error
+	if err = p.SetEnv(long, names...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// SetChoices sets allowed values of a Param registered under specified long
+// name. Values given to the Param from command line, environment or
+// configuration must be one of choices or parsing fails with
+// ErrInvalidChoice. Choices are printed and can be retrieved using Choices.
+// Calling SetChoices with no choices allows any value. Only params that take
+// a value can have choices and a default value must be one of them. If an
+// error occurs it is ErrRegister or a descendant.
+func (p *Parameters) SetChoices(long string, choices ...string) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	if !param.raw && !param.takesValue() {
+		return fmt.Errorf("%w: parameter '%s' does not take a value", ErrRegister, long)
+	}
+	var prev = param.choices
+	param.choices = append([]string(nil), choices...)
+	if err := param.checkDefault(); err != nil {
+		param.choices = prev
+		return err
+	}
+	return nil
+}
+
+// MustSetChoices is like SetChoices except the function panics on error.
+// Returns a Command that the param choices were set on.
+func (p *Parameters) MustSetChoices(long string, choices ...string) *Command {
+	var err error
+	if err = p.SetChoices(long, choices...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// Choices returns a copy of allowed values of a Param registered under
+// specified long name or nil if the Param has no choices or is not found.
+func (p *Parameters) Choices(long string) []string {
+	var param, ok = p.longparams[long]
+	if !ok || len(param.choices) == 0 {
+		return nil
+	}
+	return append([]string(nil), param.choices...)
+}
+
+// AddValidator adds a validator to a Param registered under specified long
+// name. Validators are run in order as they are added if the Param was
+// parsed from command line, environment or configuration, after parsing and
+// before any handlers of matched Commands are called. If a validator
+// returns a non-nil error no handlers are called and the error is returned
+// by Parse as ErrValidate. If an error occurs it is ErrRegister or a
+// descendant.
+func (p *Parameters) AddValidator(long string, validator Validator) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	if validator == nil {
+		return fmt.Errorf("%w: invalid validator", ErrRegister)
+	}
+	param.validators = append(param.validators, validator)
+	return nil
+}
+
+// MustAddValidator is like AddValidator except the function panics on error.
+// Returns a Command that the param validator was added to.
+func (p *Parameters) MustAddValidator(long string, validator Validator) *Command {
+	var err error
+	if err = p.AddValidator(long, validator); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddRepeatedParam registers a new prefixed Param that can be specified
+// multiple times in these Parameters.
+//
+// Value must be a pointer to a slice of a supported Go type. Argument
+// following each occurrence of the Param is converted to the slice element
+// type and appended to the slice. Slice is cleared on first occurrence of
+// the Param. All raw arguments given to the Param can be retrieved using
+// Context.Values.
+//
+// Other rules of AddParam apply.
+func (p *Parameters) AddRepeatedParam(long, short, help string, required bool, value interface{}) error {
+	var param = NewParameter(help, required, false, value)
+	param.repeated = true
+	return p.addParam(long, short, param)
+}
+
+// MustAddRepeatedParam is like AddRepeatedParam except the function panics on
+// error. Returns a Command that the param was added to.
+func (p *Parameters) MustAddRepeatedParam(long, short, help string, required bool, value interface{}) *Command {
+	var err error
+	if err = p.AddRepeatedParam(long, short, help, required, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddFlag registers a new prefixed boolean Param that takes no value in
+// these Parameters. If value is not nil it is set to true if the param was
+// parsed. Unlike a Param registered using AddParam with a bool value no value
+// argument follows the param, e.g. "--verbose" instead of "--verbose true".
+// Flags can be combined in short form, e.g. "-abc".
+//
+// Other rules of AddParam apply.
+func (p *Parameters) AddFlag(long, short, help string, value *bool) error {
+	var param = NewParameter(help, false, false, nil)
+	if value != nil {
+		param.value = value
+	}
+	param.flag = true
+	return p.addParam(long, short, param)
+}
+
+// MustAddFlag is like AddFlag except the function panics on error.
+// Returns a Command that the param was added to.
+func (p *Parameters) MustAddFlag(long, short, help string, value *bool) *Command {
+	var err error
+	if err = p.AddFlag(long, short, help, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddCounterParam registers a new prefixed Param that counts the number of
+// times it was specified in these Parameters.
+//
+// Counter param takes no value and can be specified multiple times, in
+// combined short form as well, e.g. "-vvv", "-v -v" or "--verbose --verbose".
+// Each occurrence increments the count which can be retrieved using
+// Context.Count and is written to value if it is not nil.
+//
+// Other rules of AddParam apply.
+func (p *Parameters) AddCounterParam(long, short, help string, value *int) error {
+	var param = NewParameter(help, false, false, nil)
+	if value != nil {
+		param.value = value
+	}
+	param.counted = true
+	return p.addParam(long, short, param)
+}
+
+// MustAddCounterParam is like AddCounterParam except the function panics on
+// error. Returns a Command that the param was added to.
+func (p *Parameters) MustAddCounterParam(long, short, help string, value *int) *Command {
+	var err error
+	if err = p.AddCounterParam(long, short, help, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddNegatableParam registers a new prefixed boolean Param that is true by
+// default and can be negated using a long name prefixed with "no-", e.g.
+// "--color" and "--no-color" in these Parameters. Negated long name must be
+// unique in these Parameters as well.
+//
+// Negatable param takes no value. If value is not nil it is set to true on
+// each parse and set to false if the negated form of the param is parsed.
+// Context.Value returns "true" or "false" if the param was parsed.
+//
+// Other rules of AddParam apply.
+func (p *Parameters) AddNegatableParam(long, short, help string, value *bool) error {
+	var param = NewParameter(help, false, false, nil)
+	if value != nil {
+		param.value = value
+	}
+	param.negatable = true
+	return p.addParam(long, short, param)
+}
+
+// MustAddNegatableParam is like AddNegatableParam except the function panics
+// on error. Returns a Command that the param was added to.
+func (p *Parameters) MustAddNegatableParam(long, short, help string, value *bool) *Command {
+	var err error
+	if err = p.AddNegatableParam(long, short, help, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddVariadicParam registers a variadic raw Param under specified name which
+// must be unique in long Parameters names. Variadic param takes all remaining
+// raw arguments and must be registered last. No params can be registered
+// after it and it disables command's ability to have sub commands.
+//
+// Min specifies minimum number of arguments the param requires and if
+// greater than 0 the param is required. Max specifies maximum number of
+// arguments the param takes or no limit if 0.
+//
+// If value is not nil it must be a pointer to a slice of a supported Go type.
+// Each argument is converted to the slice element type and appended to the
+// slice. All raw arguments can be retrieved using Context.Values.
+//
+// Other rules of AddRawParam apply.
+func (p *Parameters) AddVariadicParam(name, help string, min, max int, value interface{}) error {
+	var param = NewParameter(help, min > 0, true, value)
+	param.repeated = true
+	param.variadic = true
+	param.min, param.max = min, max
+	return p.addParam(name, "", param)
+}
+
+// MustAddVariadicParam is like AddVariadicParam except the function panics on
+// error. Returns a Command that the param was added to.
+func (p *Parameters) MustAddVariadicParam(name, help string, min, max int, value interface{}) *Command {
+	var err error
+	if err = p.AddVariadicParam(name, help, min, max, value); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
 // Parse parses self from state arguments and updates state.
 // If an error occurs it will be an ErrParse or a descendant.
 // Returns nil if all required parameters were parsed.
@@ -789,93 +1979,173 @@ func (p *Parameters) Parse(state *State) error {
 		return ErrNoDefinitions
 	}
 	var err error
-	var arg string
+	var arg, value string
 	var kind Argument
 	var param *Parameter
-	var exists bool
-	var i int
-	for i = 0; i < paramcount; {
-		arg, kind = state.Next()
+	var exists, attached bool
+	// If any of the params can be specified multiple times parsing continues
+	// after all params were parsed.
+	var repeated = p.hasMultiple()
+	// i is the count of parsed params, rawidx is the index of next raw param.
+	var i, rawidx int
+	// position is the index of the current param argument.
+	var position int
+	// operands is true once a raw param was parsed if not permuting.
+	var operands bool
+	for i = 0; i < paramcount || repeated; {
+		arg, kind, value, attached = state.next()
+		position = state.position()
+		// If not permuting prefixed arguments and terminators following raw
+		// params are raw.
+		if operands && (kind == LongArgument || kind == ShortArgument || kind == CombinedArgument || kind == TerminatorArgument) {
+			arg, kind, value, attached = state.Peek(), TextArgument, "", false
+		}
 		switch kind {
 		case InvalidArgument:
 			return ErrInvalidArgument
 		case NoArgument:
 			goto checkRequired
+		case TerminatorArgument:
+			// Following arguments can be raw params only.
+			state.Terminate()
+			continue
 		case TextArgument:
-			// Start of raw params, skip prefixed.
-			for i < paramcount {
-				if param = p.longparams[p.longindexes[i]]; !param.raw {
-					i++
+			// Start of raw params, skip prefixed and full variadic.
+			for rawidx < paramcount {
+				if param = p.longparams[p.longindexes[rawidx]]; !param.raw || param.full() {
+					rawidx++
 					continue
 				}
 				break
 			}
 			// No defined raw params, assume sub command name.
-			if i >= paramcount {
+			if rawidx >= paramcount {
 				goto checkRequired
 			}
-			i++
+			// Variadic param takes remaining raw arguments.
+			if !param.variadic {
+				rawidx++
+			}
+			operands = !state.Permute
 		case ShortArgument:
 			if param, exists = p.shortparams[arg]; !exists {
 				return fmt.Errorf("%w: short parameter '%s'", ErrNotFound, arg)
 			}
-			i++
 		case LongArgument:
-			if param, exists = p.longparams[arg]; !exists {
-				return fmt.Errorf("%w: long parameter '%s'", ErrNotFound, arg)
+			if param, arg, err = p.find(state, arg); err != nil {
+				return err
 			}
-			i++
 		case CombinedArgument:
 			// Parse all combined args and continue.
 			var shorts = strings.Split(arg, "")
 			var short string
+			var offset int
 			for _, short = range shorts {
 				if param, exists = p.shortparams[short]; !exists {
 					return fmt.Errorf("%w: short parameter '%s'", ErrNotFound, short)
 				}
-				if param.value != nil {
-					return fmt.Errorf("%w: short parameter '%s' requires argument, cannot combine", ErrParse, short)
+				offset += len(short)
+				if param.takesValue() {
+					// A short parameter with value ends combined arguments.
+					// The rest of the argument without a leading "=" is its'
+					// attached value, e.g. "-xzfarchive.tgz" or
+					// "-xzf=archive.tgz" or if there is no rest the value is
+					// the argument following, e.g. "-xzf archive.tgz".
+					if param.parsed && !param.repeated {
+						return fmt.Errorf("%w: %s", ErrDuplicateParameter, short)
+					}
+					if value = arg[offset:]; strings.HasPrefix(value, "=") {
+						value = value[1:]
+					} else if value == "" {
+						if !state.Skip() {
+							return fmt.Errorf("%w: parameter '%s' requires a value", ErrParse, short)
+						}
+						value = state.Peek()
+					}
+					if !param.parsed {
+						i++
+					}
+					if err = param.set(value, state.converters); err != nil {
+						return err
+					}
+					param.mark(SourceCommandLine, position)
+					break
 				}
 				// Param is specified multiple times.
-				if param.parsed {
+				if param.parsed && !param.counted {
 					return fmt.Errorf("%w: combined parameter '%s' specified multiple times", ErrParse, short)
 				}
-				param.parsed = true
-				i++
+				if !param.parsed {
+					i++
+				}
+				if param.counted {
+					err = param.set(short, state.converters)
+				} else if param.negatable || param.flag {
+					err = param.set("true", state.converters)
+				} else {
+					param.parsed = true
+				}
+				if err != nil {
+					return err
+				}
+				param.mark(SourceCommandLine, position)
 			}
 			state.Skip()
 			continue
 		}
 		// Param is specified multiple times.
-		if param.parsed {
+		if param.parsed && !param.multiple() {
 			return fmt.Errorf("%w: %s", ErrDuplicateParameter, arg)
 		}
-		// Parse value argument for params with value.
-		if param.value != nil {
-			// Advance argument for prefixed params.
+		// Negatable params take the value from the form they were given in,
+		// flags are true.
+		if param.negatable || param.flag {
+			if attached {
+				return fmt.Errorf("%w: parameter '%s' does not take a value", ErrParse, arg)
+			}
+			arg = strconv.FormatBool(kind != LongArgument || !param.negated(arg))
+		} else if param.takesValue() {
+			// Parse value argument for params with value.
+			// Use attached value or advance argument for prefixed params.
 			if !param.raw {
-				if !state.Skip() {
-					return fmt.Errorf("%w: parameter '%s' requires a value", ErrParse, arg)
+				if attached {
+					arg = value
+				} else {
+					if !state.Skip() {
+						return fmt.Errorf("%w: parameter '%s' requires a value", ErrParse, arg)
+					}
+					arg = state.Peek()
 				}
-				arg = state.Peek()
-			}
-			// Set value.
-			if err = stringToGoValue(arg, param.value); err != nil {
-				return err
 			}
+		} else if attached {
+			return fmt.Errorf("%w: parameter '%s' does not take a value", ErrParse, arg)
+		}
+		// Set value and advance.
+		if !param.parsed {
+			i++
 		}
-		// Advance.
-		param.rawvalue = arg
-		param.parsed = true
+		if err = param.set(arg, state.converters); err != nil {
+			return err
+		}
+		param.mark(SourceCommandLine, position)
 		if !state.Skip() {
 			break
 		}
 	}
 checkRequired:
-	// Check all required params were parsed.
-	for arg, param = range p.longparams {
-		if param.required && !param.parsed {
-			return fmt.Errorf("%w: required parameter '%s' not specified", ErrParse, arg)
+	// Read params not parsed from command line from environment, then from
+	// loaded configuration.
+	if err = p.parseEnv(state, state.path(p.cmd)); err != nil {
+		return err
+	}
+	if err = p.parseConfig(state); err != nil {
+		return err
+	}
+	// If interactive, required params are prompted for and checked once all
+	// arguments were parsed, see State.VisitMatches.
+	if !state.Interactive {
+		if err = p.check(); err != nil {
+			return err
 		}
 	}
 	if state.ArgumentCount() == 0 {
@@ -884,8 +2154,105 @@ checkRequired:
 	return nil
 }
 
+// anyParsed returns true if any Param was parsed.
+func (p *Parameters) anyParsed() bool {
+	for _, long := range p.longindexes {
+		if p.longparams[long].parsed {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks all required params were parsed and relationships between
+// params.
+func (p *Parameters) check() error {
+	var param *Parameter
+	for _, long := range p.longindexes {
+		param = p.longparams[long]
+		if param.required && !param.parsed {
+			return fmt.Errorf("%w: required parameter '%s' not specified", ErrParse, long)
+		}
+		if param.variadic && len(param.rawvalues) < param.min {
+			return fmt.Errorf("%w: parameter '%s' requires at least %d arguments", ErrParse, long, param.min)
+		}
+	}
+	return p.checkConstraints()
+}
+
+// parseEnv sets values of Parameters not parsed from command line arguments
+// from environment variables, if defined. Path is the Command path used to
+// derive variable names.
+func (p *Parameters) parseEnv(state *State, path []string) error {
+	var lookup = state.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	var param *Parameter
+	var long, name, value string
+	var ok bool
+	for _, long = range p.longindexes {
+		if param = p.longparams[long]; param.parsed {
+			continue
+		}
+		for _, name = range param.envNames(state.EnvPrefix, path) {
+			if value, ok = lookup(name); !ok {
+				continue
+			}
+			if err := param.apply([]string{value}, state.converters); err != nil {
+				return fmt.Errorf("%w: environment variable '%s'", err, name)
+			}
+			param.mark(SourceEnvironment, -1)
+			break
+		}
+	}
+	return nil
+}
+
+// find returns a Parameter by long name and the name it was found under or
+// if State allows abbreviations, a Parameter whose long name is uniquely
+// prefixed by long and its' full name. Returns ErrNotFound if not found or
+// ErrAmbiguous if the abbreviation matches multiple Parameters.
+func (p *Parameters) find(state *State, long string) (*Parameter, string, error) {
+	var param, ok = p.longparams[long]
+	if ok {
+		return param, long, nil
+	}
+	if !state.Abbreviate {
+		return nil, "", fmt.Errorf("%w: long parameter '%s'", ErrNotFound, long)
+	}
+	var candidates []string
+	var key, name string
+	var match *Parameter
+	for key, param = range p.longparams {
+		if !strings.HasPrefix(key, long) {
+			continue
+		}
+		candidates = append(candidates, key)
+		// Negated name is a different match of the same param.
+		if match != nil && (match != param || match.negated(name) != param.negated(key)) {
+			ok = true
+		}
+		match, name = param, key
+	}
+	if match == nil {
+		return nil, "", fmt.Errorf("%w: long parameter '%s'", ErrNotFound, long)
+	}
+	if ok {
+		sort.Strings(candidates)
+		return nil, "", fmt.Errorf("%w: long parameter '%s' matches: %s", ErrAmbiguous, long, strings.Join(candidates, ", "))
+	}
+	return match, name, nil
+}
+
 // addParam is the implementation of AddParam minus the checks of exposed API.
-func (p *Parameters) addParam(long, short, help string, required, raw bool, value interface{}) error {
+// It registers param under specified long and optional short name.
+func (p *Parameters) addParam(long, short string, param *Parameter) error {
+	var raw, required, value = param.raw, param.required, param.value
+	var negation string
+	if param.negatable {
+		negation = "no-" + long
+	}
 	// Long name must not be empty and short name must be max one char long.
 	if long == "" || len(short) > 1 {
 		return fmt.Errorf("%w: invalid name", ErrRegister)
@@ -895,22 +2262,29 @@ func (p *Parameters) addParam(long, short, help string, required, raw bool, valu
 	if _, ok = p.longparams[long]; ok {
 		return fmt.Errorf("%w: long parameter name '%s'", ErrDuplicate, long)
 	}
+	if _, ok = p.longparams[negation]; ok && negation != "" {
+		return fmt.Errorf("%w: long parameter name '%s'", ErrDuplicate, negation)
+	}
 	// No short duplicates if not empty.
 	if _, ok = p.shortparams[short]; ok && short != "" {
 		return fmt.Errorf("%w: short parameter name '%s'", ErrDuplicate, short)
 	}
 	// Disallow adding optional raw parameters if command expects sub commands.
-	if p.cmd.CommandCount() > 0 && raw && !required {
+	if p.cmd.CommandCount() > 0 && raw && (!required || param.variadic) {
 		return fmt.Errorf("%w: cannot register optional raw parameter on a command with sub commands", ErrRegister)
 	}
 	// Raw params can only be registered after prefixed params.
 	// Optional raw params can only be registered after any required raw params.
-	var param *Parameter
-	if param = p.last(); param != nil && param.raw {
+	// Nothing can be registered after a variadic param.
+	var last *Parameter
+	if last = p.last(); last != nil && last.variadic {
+		return fmt.Errorf("%w: cannot register parameter after variadic parameter", ErrRegister)
+	}
+	if last != nil && last.raw {
 		if !raw {
 			return fmt.Errorf("%w: cannot register prefixed parameter after raw parameter", ErrRegister)
 		}
-		if !param.required {
+		if !last.required {
 			if !required {
 				return fmt.Errorf("%w: cannot register multiple optional parameters", ErrRegister)
 			}
@@ -924,13 +2298,37 @@ func (p *Parameters) addParam(long, short, help string, required, raw bool, valu
 		}
 	} else {
 		// Value must be a valid pointer to a Go value.
-		if v := reflect.ValueOf(value); !v.IsValid() || v.Kind() != reflect.Ptr {
+		if v := reflect.ValueOf(value); !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() {
 			return fmt.Errorf("%w: invalid value", ErrRegister)
 		}
 	}
+	// Variadic params need a valid count range.
+	if param.variadic && (param.min < 0 || param.max < 0 || (param.max > 0 && param.max < param.min)) {
+		return fmt.Errorf("%w: invalid variadic parameter count range", ErrRegister)
+	}
+	// Repeated params need a pointer to a slice, optional for variadic.
+	if param.repeated && (value != nil || !param.variadic) {
+		if v := reflect.ValueOf(value); !v.IsValid() || v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+			return fmt.Errorf("%w: repeated parameter requires a pointer to a slice", ErrRegister)
+		}
+	}
+	// Current Go value is the default value. Negatable params are true.
+	if value != nil {
+		var v = reflect.Indirect(reflect.ValueOf(value))
+		if param.negatable {
+			v = reflect.ValueOf(true)
+		}
+		param.setDefault(v, "")
+	} else if param.negatable {
+		param.defstring = "true"
+	}
 	// Register a new param.
-	param = NewParameter(help, required, raw, value)
+	param.name = long
 	p.longparams[long] = param
+	if negation != "" {
+		p.longparams[negation] = param
+		p.longtoshort[negation] = ""
+	}
 	if short != "" {
 		p.shortparams[short] = param
 	}
@@ -939,6 +2337,30 @@ func (p *Parameters) addParam(long, short, help string, required, raw bool, valu
 	return nil
 }
 
+// removeParam removes a Param registered under long name along with its'
+// short name and negation, if any.
+func (p *Parameters) removeParam(long string) {
+	var param, ok = p.longparams[long]
+	if !ok {
+		return
+	}
+	if short := p.longtoshort[long]; short != "" {
+		delete(p.shortparams, short)
+	}
+	if param.negatable {
+		delete(p.longparams, "no-"+long)
+		delete(p.longtoshort, "no-"+long)
+	}
+	delete(p.longparams, long)
+	delete(p.longtoshort, long)
+	for i, name := range p.longindexes {
+		if name == long {
+			p.longindexes = append(p.longindexes[:i], p.longindexes[i+1:]...)
+			break
+		}
+	}
+}
+
 // last returns the last defined arg or nil if none registered.
 func (p *Parameters) last() *Parameter {
 	if len(p.longindexes) == 0 {
@@ -947,11 +2369,23 @@ func (p *Parameters) last() *Parameter {
 	return p.longparams[p.longindexes[len(p.longindexes)-1]]
 }
 
-// HasOptionalRawArgs returns if Parameters contain one or more defined raw Parameters.
+// hasMultiple returns true if any of Parameters can be parsed multiple times.
+func (p *Parameters) hasMultiple() bool {
+	var name string
+	for _, name = range p.longindexes {
+		if p.longparams[name].multiple() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOptionalRawArgs returns if Parameters contain one or more defined
+// optional or variadic raw Parameters.
 func (p *Parameters) HasOptionalRawArgs() bool {
 	var param *Parameter
 	for _, param = range p.longparams {
-		if param.raw && !param.required {
+		if param.raw && (!param.required || param.variadic) {
 			return true
 		}
 	}
@@ -959,35 +2393,126 @@ func (p *Parameters) HasOptionalRawArgs() bool {
 }
 
 // stringToGoValue converts a string to a Go value or returns an error.
-func stringToGoValue(s string, i interface{}) error {
-	if err := strconvex.StringToInterface(s, i); err != nil {
+// If i implements Value its' Set method is used. Otherwise, if a converter
+// for the type i points to is found in conv, it is used. Otherwise, if i
+// implements encoding.TextUnmarshaler it is used or a default conversion is
+// performed. Strings, including of named string types, are set directly.
+func stringToGoValue(s string, i interface{}, conv converters) error {
+	var err error
+	if value, ok := i.(Value); ok {
+		err = value.Set(s)
+	} else if f, ok := conv[reflect.TypeOf(i).Elem()]; ok {
+		err = convertValue(f, s, i)
+	} else if tu, ok := i.(encoding.TextUnmarshaler); ok {
+		err = tu.UnmarshalText([]byte(s))
+	} else if v := reflect.ValueOf(i).Elem(); v.Kind() == reflect.String {
+		v.SetString(s)
+	} else {
+		err = strconvex.StringToInterface(s, i)
+	}
+	if err != nil {
 		return fmt.Errorf("%w: error converting value %s: %v", ErrConvert, s, err)
 	}
 	return nil
 }
 
+// appendGoValue converts a string to a Go value of element type of slice
+// pointed to by i and appends it to the slice or returns an error.
+func appendGoValue(s string, i interface{}, conv converters) error {
+	var v = reflect.Indirect(reflect.ValueOf(i))
+	var e = reflect.New(v.Type().Elem())
+	if err := stringToGoValue(s, e.Interface(), conv); err != nil {
+		return err
+	}
+	v.Set(reflect.Append(v, e.Elem()))
+	return nil
+}
+
+// copyValue returns a copy of v. Slices and maps are copied shallowly.
+func copyValue(v reflect.Value) reflect.Value {
+	var c = reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Slice:
+		if !v.IsNil() {
+			c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+			reflect.Copy(c, v)
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+			for _, key := range v.MapKeys() {
+				c.SetMapIndex(key, v.MapIndex(key))
+			}
+		}
+	default:
+		c.Set(v)
+	}
+	return c
+}
+
 // resetCommands recursively resets all Commands and their Parameters states.
 func resetCommands(c *Commands) {
+	var cmd *Command
+	var name, long string
+	for _, name = range c.nameindexes {
+		cmd = c.commandmap[name]
+		for _, long = range cmd.Parameters.longindexes {
+			cmd.Parameters.longparams[long].reset()
+		}
+		resetCommands(cmd.Commands)
+	}
+}
+
+// convertDefaults recursively converts default values of all Commands
+// Parameters set using SetDefault to Go values using conv.
+func convertDefaults(c *Commands, conv converters) error {
 	var cmd *Command
 	var param *Parameter
-	for _, cmd = range c.commandmap {
-		if len(cmd.Parameters.longparams) > 0 {
-			for _, param = range cmd.Parameters.longparams {
-				param.parsed = false
-				param.rawvalue = ""
+	var name, long string
+	for _, name = range c.nameindexes {
+		cmd = c.commandmap[name]
+		for _, long = range cmd.Parameters.longindexes {
+			if param = cmd.Parameters.longparams[long]; !param.defconvert {
+				continue
+			}
+			if err := param.convertDefault(conv); err != nil {
+				return fmt.Errorf("%w: default value of parameter '%s'", err, long)
 			}
 		}
-		resetCommands(cmd.Commands)
+		if err := convertDefaults(cmd.Commands, conv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitCommands recursively writes staged values of all Commands Parameters
+// to their Go values.
+func commitCommands(c *Commands) {
+	var cmd *Command
+	var name, long string
+	for _, name = range c.nameindexes {
+		cmd = c.commandmap[name]
+		for _, long = range cmd.Parameters.longindexes {
+			cmd.Parameters.longparams[long].commit()
+		}
+		commitCommands(cmd.Commands)
 	}
 }
 
 // printCommands is a recursive printer or registered Commands and Parameters.
+// Path is the path of commands and prefix the environment variable prefix
+// used to print derived environment variable names if not empty.
 // Lines are written to sb from current commands with the indent depth(*tab).
-func printCommands(sb *strings.Builder, commands *Commands, indent int) {
+func printCommands(sb *strings.Builder, commands *Commands, indent int, path []string, prefix string) {
 	for _, commandname := range commands.nameindexes {
 		command := commands.commandmap[commandname]
 		writeIndent(sb, indent)
 		sb.WriteString(commandname)
+		for _, alias := range command.aliases {
+			sb.WriteString(", ")
+			sb.WriteString(alias)
+		}
 		if command.help != "" {
 			sb.WriteRune('\t')
 			sb.WriteString(command.help)
@@ -998,30 +2523,53 @@ func printCommands(sb *strings.Builder, commands *Commands, indent int) {
 			shortparam := command.Parameters.longtoshort[paramlong]
 			writeIndent(sb, indent)
 			sb.WriteRune('\t')
+			var open, close = '[', ']'
 			if param.required {
-				if !param.raw {
-					sb.WriteString("<--")
-				} else {
-					sb.WriteRune('<')
+				open, close = '<', '>'
+			}
+			sb.WriteRune(open)
+			for i, name := range append([]string{paramlong}, param.aliases...) {
+				if i > 0 {
+					sb.WriteRune('|')
 				}
-				sb.WriteString(paramlong)
-				sb.WriteRune('>')
-			} else {
 				if !param.raw {
-					sb.WriteString("[--")
-				} else {
-					sb.WriteRune('[')
+					sb.WriteString("--")
 				}
-				sb.WriteString(paramlong)
-				sb.WriteRune(']')
+				if param.negatable {
+					sb.WriteString("[no-]")
+				}
+				sb.WriteString(name)
+			}
+			sb.WriteRune(close)
+			if param.multiple() {
+				sb.WriteString("...")
 			}
 			if shortparam != "" {
 				sb.WriteString("\t-")
 				sb.WriteString(shortparam)
 			}
-			if param.value != nil {
+			if param.takesValue() {
+				var t = reflect.Indirect(reflect.ValueOf(param.value)).Type()
+				if param.repeated {
+					t = t.Elem()
+				}
 				sb.WriteString("\t(")
-				sb.WriteString(reflect.Indirect(reflect.ValueOf(param.value)).Type().Kind().String())
+				sb.WriteString(t.Kind().String())
+				sb.WriteRune(')')
+			}
+			if len(param.choices) > 0 {
+				sb.WriteString("\t(choices: ")
+				sb.WriteString(strings.Join(param.choices, ", "))
+				sb.WriteRune(')')
+			}
+			if param.defstring != "" {
+				sb.WriteString("\t(default: ")
+				sb.WriteString(param.defstring)
+				sb.WriteRune(')')
+			}
+			if env := param.envNames(prefix, appendPath(path, commandname)); len(env) > 0 {
+				sb.WriteString("\t(env: ")
+				sb.WriteString(strings.Join(env, ", "))
 				sb.WriteRune(')')
 			}
 			if param.help != "" {
@@ -1030,11 +2578,55 @@ func printCommands(sb *strings.Builder, commands *Commands, indent int) {
 			}
 			sb.WriteRune('\n')
 		}
+		for _, c := range command.Parameters.constraints {
+			writeIndent(sb, indent)
+			sb.WriteRune('\t')
+			c.print(sb, command.Parameters)
+			sb.WriteRune('\n')
+		}
 		sb.WriteRune('\n')
-		if len(command.Commands.commandmap) > 0 {
-			printCommands(sb, command.Commands, indent+1)
+		if command.CommandCount() > 0 {
+			printCommands(sb, command.Commands, indent+1, appendPath(path, commandname), prefix)
+		}
+	}
+}
+
+// appendPath returns a copy of path with name appended if not empty.
+func appendPath(path []string, name string) []string {
+	var result = make([]string, 0, len(path)+1)
+	result = append(result, path...)
+	if name != "" {
+		result = append(result, name)
+	}
+	return result
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	var ra, rb = []rune(a), []rune(b)
+	var prev, curr = make([]int, len(rb)+1), make([]int, len(rb)+1)
+	var i, j, cost int
+	for j = range prev {
+		prev[j] = j
+	}
+	for i = 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j = 1; j <= len(rb); j++ {
+			cost = 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = curr[j-1] + 1
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
 		}
+		prev, curr = curr, prev
 	}
+	return prev[len(rb)]
 }
 
 // writeIndent writes an indent string of n depth to sb.
@@ -7,6 +7,9 @@ package commandline
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -28,7 +31,7 @@ func TestParserNext(t *testing.T) {
 		t.Fatal("Error parsing argument.")
 	}
 	cl.arguments = []string{"--"}
-	if arg, kind = cl.Next(); arg != "" || kind != InvalidArgument {
+	if arg, kind = cl.Next(); arg != "" || kind != TerminatorArgument {
 		t.Fatal("Error parsing argument.")
 	}
 	cl.arguments = []string{"---"}
@@ -51,6 +54,44 @@ func TestParserNext(t *testing.T) {
 	if arg, kind = cl.Next(); arg != "foo" || kind != CombinedArgument {
 		t.Fatal("Error parsing argument.")
 	}
+	var value string
+	var attached bool
+	cl.arguments = []string{"--foo=bar"}
+	if arg, kind = cl.Next(); arg != "foo" || kind != LongArgument {
+		t.Fatal("Error parsing argument.")
+	}
+	if value, attached = cl.Attached(); value != "bar" || !attached {
+		t.Fatal("Error parsing attached value.")
+	}
+	cl.arguments = []string{"--foo="}
+	if value, attached = cl.Attached(); value != "" || !attached {
+		t.Fatal("Error parsing attached value.")
+	}
+	cl.arguments = []string{"-f=bar"}
+	if arg, kind = cl.Next(); arg != "f" || kind != ShortArgument {
+		t.Fatal("Error parsing argument.")
+	}
+	if value, attached = cl.Attached(); value != "bar" || !attached {
+		t.Fatal("Error parsing attached value.")
+	}
+	cl.arguments = []string{"-fbar=baz"}
+	if arg, kind = cl.Next(); arg != "fbar=baz" || kind != CombinedArgument {
+		t.Fatal("Error parsing argument.")
+	}
+	if _, attached = cl.Attached(); attached {
+		t.Fatal("Error parsing attached value.")
+	}
+	cl.arguments = []string{"--=bar"}
+	if arg, kind = cl.Next(); arg != "" || kind != InvalidArgument {
+		t.Fatal("Error parsing argument.")
+	}
+	cl.arguments = []string{"--", "-foo", "--bar"}
+	if !cl.Terminate() {
+		t.Fatal("Error terminating arguments.")
+	}
+	if arg, kind = cl.Next(); arg != "-foo" || kind != TextArgument {
+		t.Fatal("Error parsing terminated argument.")
+	}
 }
 
 // Test command registrations and execution.
@@ -370,9 +411,30 @@ func TestPrefixedCombined(t *testing.T) {
 	if err = cl.Parse([]string{"foo", "-abXde"}); err == nil {
 		t.Fatal("Failed detecting invalid short name in combined params.")
 	}
-	// Cannot combine required params into combined short params.
-	if err = cl.Parse([]string{"foo", "-abcdef", "filip"}); err == nil {
-		t.Fatal("Failed detecting short param with required value being combined.")
+	// Last combined short param can take a value from the following argument.
+	if err = cl.Parse([]string{"foo", "-abcdef", "filip"}); err != nil {
+		t.Fatal(err)
+	}
+	if filip != "filip" {
+		t.Fatal("Combined short param value not set.")
+	}
+	// Last combined short param can take a value from the rest of argument.
+	if err = cl.Parse([]string{"foo", "-abfphilip"}); err != nil {
+		t.Fatal(err)
+	}
+	if filip != "philip" {
+		t.Fatal("Combined short param value not set.")
+	}
+	// Short param with value ends combined short params.
+	if err = cl.Parse([]string{"foo", "-afbc"}); err != nil {
+		t.Fatal(err)
+	}
+	if filip != "bc" {
+		t.Fatal("Combined short param value not set.")
+	}
+	// Last combined short param requires a value.
+	if err = cl.Parse([]string{"foo", "-abcdef"}); err == nil {
+		t.Fatal("Failed detecting missing value of combined short param.")
 	}
 	// Parse combined params ok.
 	if err = cl.Parse([]string{"foo", "-aa"}); err == nil {
@@ -384,6 +446,1000 @@ func TestPrefixedCombined(t *testing.T) {
 	}
 }
 
+// Test values attached to prefixed params.
+func TestAttachedValues(t *testing.T) {
+	var output, file string
+	var foo = func(ctx Context) error {
+		if ctx.Value("output") != "json" {
+			t.Fatal("Unexpected attached long parameter value.")
+		}
+		if ctx.Value("file") != "file.txt" {
+			t.Fatal("Unexpected attached short parameter value.")
+		}
+		return nil
+	}
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", foo).
+		MustAddParam("output", "o", "", false, &output).
+		MustAddParam("file", "f", "", false, &file).
+		MustAddParam("verbose", "v", "", false, nil)
+	if err = cl.Parse([]string{"foo", "--output=json", "-ffile.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if output != "json" || file != "file.txt" {
+		t.Fatal("Attached values not converted.")
+	}
+	if err = cl.Parse([]string{"foo", "-o=json", "--file=file.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"foo", "-o", "json", "-f", "file.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	// Value attached to a param that takes no value.
+	if err = cl.Parse([]string{"foo", "--verbose=yes"}); err == nil {
+		t.Fatal("Failed detecting value attached to a flag.")
+	}
+	// Short param with value can be last in combined params.
+	if err = cl.Parse([]string{"foo", "-vffile.txt", "-o", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	// Attached value of last combined param may be separated by "=".
+	if err = cl.Parse([]string{"foo", "-vf=file.txt", "-o", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if file != "file.txt" {
+		t.Fatal("Leading '=' not stripped from combined param value.")
+	}
+}
+
+// Test "--" end of options terminator.
+func TestTerminator(t *testing.T) {
+	var exec = func(ctx Context) error {
+		var a = ctx.Arguments()
+		if len(a) != 3 || a[0] != "ls" || a[1] != "-la" || a[2] != "--" {
+			t.Fatal("Unexpected raw command arguments.")
+		}
+		return nil
+	}
+	var rm = func(ctx Context) error {
+		if ctx.Value("file") != "-foo" {
+			t.Fatal("Unexpected raw parameter value.")
+		}
+		return nil
+	}
+	var cl = NewState()
+	var err error
+	cl.MustAddRawCommand("exec", "", exec).
+		MustAddParam("verbose", "v", "", false, nil)
+	cl.MustAddCommand("rm", "", rm).
+		MustAddParam("force", "f", "", false, nil).
+		MustAddRawParam("file", "", true, nil)
+	cl.MustAddCommand("ls", "", nil)
+	if err = cl.Parse([]string{"exec", "--", "ls", "-la", "--"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"exec", "-v", "--", "ls", "-la", "--"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"rm", "--", "-foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"rm", "-f", "--", "-foo"}); err != nil {
+		t.Fatal(err)
+	}
+	// "-foo" is not a registered parameter.
+	if err = cl.Parse([]string{"rm", "-foo"}); err == nil {
+		t.Fatal("Failed detecting unregistered parameters.")
+	}
+	// Arguments following a terminator are not parameters.
+	if err = cl.Parse([]string{"rm", "--", "-f", "-foo"}); err == nil {
+		t.Fatal("Failed detecting extra arguments.")
+	}
+	// Arguments following a terminator are not commands.
+	if err = cl.Parse([]string{"--", "ls"}); err == nil {
+		t.Fatal("Failed detecting arguments following a terminator as a command.")
+	}
+	// Arguments following a terminator are raw params of an empty command.
+	var file string
+	cl.MustAddCommand("", "", nil).
+		MustAddParam("verbose", "v", "", false, nil).
+		MustAddRawParam("file", "", false, &file)
+	if err = cl.Parse([]string{"--", "-foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if file != "-foo" {
+		t.Fatal("Unexpected empty command raw parameter value.")
+	}
+	if err = cl.Parse([]string{"-v", "--", "-bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if file != "-bar" {
+		t.Fatal("Unexpected empty command raw parameter value.")
+	}
+}
+
+// Test repeated params.
+func TestRepeated(t *testing.T) {
+	var tags []string
+	var ports []int
+	var foo = func(ctx Context) error {
+		var values = ctx.Values("tag")
+		if len(values) != 3 || values[0] != "a" || values[1] != "b" || values[2] != "c" {
+			t.Fatal("Unexpected repeated parameter values.")
+		}
+		if ctx.Value("tag") != "c" {
+			t.Fatal("Unexpected repeated parameter value.")
+		}
+		return nil
+	}
+	var cl = NewState()
+	var err error
+	var cmd = cl.MustAddCommand("foo", "", foo)
+	// Repeated params need a pointer to a slice.
+	var bad string
+	if err = cmd.AddRepeatedParam("bad", "", "", false, &bad); err == nil {
+		t.Fatal("Failed detecting invalid repeated parameter value.")
+	}
+	if err = cmd.AddRepeatedParam("bad", "", "", false, nil); err == nil {
+		t.Fatal("Failed detecting invalid repeated parameter value.")
+	}
+	cmd.MustAddRepeatedParam("tag", "t", "", true, &tags).
+		MustAddRepeatedParam("port", "p", "", false, &ports).
+		MustAddRawParam("name", "", false, nil)
+	tags = []string{"default"}
+	if err = cl.Parse([]string{"foo", "--tag", "a", "-tb", "--tag=c", "-p", "1", "-p2", "name"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 3 || tags[0] != "a" || tags[1] != "b" || tags[2] != "c" {
+		t.Fatal("Repeated parameter values not appended.")
+	}
+	if len(ports) != 2 || ports[0] != 1 || ports[1] != 2 {
+		t.Fatal("Repeated parameter values not converted.")
+	}
+	// Invalid element value.
+	if err = cl.Parse([]string{"foo", "--tag", "a", "--port", "x"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid repeated parameter value.")
+	}
+	// Required repeated param.
+	if err = cl.Parse([]string{"foo", "--port", "1"}); err == nil {
+		t.Fatal("Failed detecting missing required repeated parameter.")
+	}
+}
+
+// Test counter params.
+func TestCounter(t *testing.T) {
+	var verbose int
+	var count int
+	var foo = func(ctx Context) error {
+		if ctx.Count("verbose") != count {
+			t.Fatal("Unexpected counter parameter count.")
+		}
+		return nil
+	}
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", foo).
+		MustAddCounterParam("verbose", "v", "", &verbose).
+		MustAddCounterParam("debug", "d", "", nil).
+		MustAddParam("quiet", "q", "", false, nil)
+	count = 3
+	if err = cl.Parse([]string{"foo", "-vvv"}); err != nil {
+		t.Fatal(err)
+	}
+	if verbose != 3 {
+		t.Fatal("Counter parameter value not set.")
+	}
+	count = 4
+	if err = cl.Parse([]string{"foo", "-v", "--verbose", "-qvd", "--verbose"}); err != nil {
+		t.Fatal(err)
+	}
+	if verbose != 4 {
+		t.Fatal("Counter parameter value not set.")
+	}
+	// Counters take no value.
+	if err = cl.Parse([]string{"foo", "--verbose=2"}); err == nil {
+		t.Fatal("Failed detecting value attached to a counter.")
+	}
+	// Non-counters still cannot be repeated.
+	if err = cl.Parse([]string{"foo", "-vqq"}); err == nil {
+		t.Fatal("Failed detecting duplicate short parameters.")
+	}
+}
+
+// Test negatable params.
+func TestNegatable(t *testing.T) {
+	var color bool
+	var value string
+	var foo = func(ctx Context) error {
+		if ctx.Value("color") != value {
+			t.Fatal("Unexpected negatable parameter value.")
+		}
+		return nil
+	}
+	var cl = NewState()
+	var err error
+	var cmd = cl.MustAddCommand("foo", "", foo).
+		MustAddNegatableParam("color", "c", "", &color).
+		MustAddNegatableParam("pager", "", "", nil).
+		MustAddParam("quiet", "q", "", false, nil)
+	// Negated name must be unique.
+	if err = cmd.AddParam("no-color", "", "", false, nil); err == nil {
+		t.Fatal("Failed detecting duplicate negated parameter name.")
+	}
+	if err = cmd.AddNegatableParam("quiet", "", "", nil); err == nil {
+		t.Fatal("Failed detecting duplicate negatable parameter name.")
+	}
+	value = ""
+	if err = cl.Parse([]string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if !color {
+		t.Fatal("Negatable parameter not true by default.")
+	}
+	value = "false"
+	if err = cl.Parse([]string{"foo", "--no-color", "--no-pager"}); err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Fatal("Negatable parameter not negated.")
+	}
+	value = "true"
+	if err = cl.Parse([]string{"foo", "--color"}); err != nil {
+		t.Fatal(err)
+	}
+	if !color {
+		t.Fatal("Negatable parameter not set.")
+	}
+	color = false
+	if err = cl.Parse([]string{"foo", "-qc"}); err != nil {
+		t.Fatal(err)
+	}
+	if !color {
+		t.Fatal("Negatable parameter not set.")
+	}
+	// Negatable params take no value.
+	if err = cl.Parse([]string{"foo", "--color=false"}); err == nil {
+		t.Fatal("Failed detecting value attached to a negatable parameter.")
+	}
+	if err = cl.Parse([]string{"foo", "--color", "--no-color"}); err == nil {
+		t.Fatal("Failed detecting duplicate negatable parameter.")
+	}
+	if !strings.Contains(cl.Print(), "[--[no-]color]") {
+		t.Fatal("Negatable parameter not printed.")
+	}
+}
+
+// Test flags with Go values.
+func TestFlag(t *testing.T) {
+	var all, long bool
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("ls", "", nil).
+		MustAddFlag("all", "a", "", &all).
+		MustAddFlag("long", "l", "", &long).
+		MustAddFlag("human", "h", "", nil)
+	if err = cl.Parse([]string{"ls", "-al"}); err != nil {
+		t.Fatal(err)
+	}
+	if !all || !long {
+		t.Fatal("Combined flags not set.")
+	}
+	if err = cl.Parse([]string{"ls", "--all"}); err != nil {
+		t.Fatal(err)
+	}
+	if !all || long {
+		t.Fatal("Flags not set.")
+	}
+	// Flags take no value.
+	if err = cl.Parse([]string{"ls", "--all=true"}); err == nil {
+		t.Fatal("Failed detecting value attached to a flag.")
+	}
+	if err = cl.Parse([]string{"ls", "--all", "true"}); err == nil {
+		t.Fatal("Failed detecting value following a flag.")
+	}
+	if strings.Contains(cl.Print(), "(bool)") {
+		t.Fatal("Flag printed as taking a value.")
+	}
+	// Flags can be required.
+	var ls = cl.MustGetCommand("ls")
+	if err = ls.SetRequired("human", true); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting required flag without value.")
+	}
+	ls.MustSetRequired("all", true)
+	if err = cl.Parse([]string{"ls", "-l"}); !errors.Is(err, ErrParse) {
+		t.Fatal("Failed detecting missing required flag.")
+	}
+}
+
+// Test permutation of prefixed and raw params.
+func TestPermute(t *testing.T) {
+	var force bool
+	var src, dst string
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("cp", "", nil).
+		MustAddParam("force", "f", "", false, nil).
+		MustAddParam("mode", "m", "", false, new(int)).
+		MustAddRawParam("src", "", true, &src).
+		MustAddRawParam("dst", "", false, &dst)
+	cl.MustAddCommand("rm", "", func(ctx Context) error {
+		force = ctx.Parsed("force")
+		return nil
+	}).
+		MustAddParam("force", "f", "", false, nil).
+		MustAddRawParam("file", "", true, nil)
+	// Strict mode, prefixed params following raw params are raw params.
+	if err = cl.Parse([]string{"cp", "src", "dst", "--force"}); err == nil {
+		t.Fatal("Failed detecting prefixed params after raw params in strict mode.")
+	}
+	if err = cl.Parse([]string{"cp", "src", "-f"}); err != nil {
+		t.Fatal(err)
+	}
+	if dst != "-f" {
+		t.Fatal("Prefixed argument following raw params not parsed as raw in strict mode.")
+	}
+	if err = cl.Parse([]string{"cp", "src", "--"}); err != nil {
+		t.Fatal(err)
+	}
+	if dst != "--" {
+		t.Fatal("Terminator following raw params not parsed as raw in strict mode.")
+	}
+	cl.Permute = true
+	if err = cl.Parse([]string{"cp", "src", "dst", "--force"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"cp", "src", "-m", "1", "dst", "-f"}); err != nil {
+		t.Fatal(err)
+	}
+	if src != "src" || dst != "dst" {
+		t.Fatal("Unexpected raw param values in permute mode.")
+	}
+	if err = cl.Parse([]string{"rm", "file", "-f"}); err != nil {
+		t.Fatal(err)
+	}
+	if !force {
+		t.Fatal("Prefixed param after raw param not parsed in permute mode.")
+	}
+	if err = cl.Parse([]string{"cp", "src", "-x"}); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Failed detecting unregistered prefixed param after raw params in permute mode.")
+	}
+	// Prefixed params are raw after a terminator in permute mode.
+	if err = cl.Parse([]string{"cp", "src", "--", "-f"}); err != nil {
+		t.Fatal(err)
+	}
+	if dst != "-f" {
+		t.Fatal("Argument following terminator not parsed as raw in permute mode.")
+	}
+}
+
+// Test variadic raw params.
+func TestVariadic(t *testing.T) {
+	var files []string
+	var numbers []int
+	var cl = NewState()
+	var err error
+	var rm = cl.MustAddCommand("rm", "", func(ctx Context) error {
+		if len(ctx.Values("file")) != len(files) {
+			t.Fatal("Unexpected variadic parameter values.")
+		}
+		return nil
+	}).
+		MustAddParam("force", "f", "", false, nil).
+		MustAddVariadicParam("file", "", 1, 3, &files)
+	// Nothing can be registered after a variadic param.
+	if err = rm.AddRawParam("extra", "", false, nil); err == nil {
+		t.Fatal("Failed detecting registration after variadic parameter.")
+	}
+	// No sub commands on a command with a variadic param.
+	if _, err = rm.AddCommand("sub", "", nil); err == nil {
+		t.Fatal("Failed detecting sub command registration on a command with variadic parameter.")
+	}
+	var sum = cl.MustAddCommand("sum", "", nil).
+		MustAddRawParam("first", "", true, nil)
+	// Invalid count range.
+	if err = sum.AddVariadicParam("numbers", "", 2, 1, &numbers); err == nil {
+		t.Fatal("Failed detecting invalid variadic parameter count range.")
+	}
+	// Value must be a pointer to a slice.
+	if err = sum.AddVariadicParam("numbers", "", 0, 0, new(int)); err == nil {
+		t.Fatal("Failed detecting invalid variadic parameter value.")
+	}
+	sum.MustAddVariadicParam("numbers", "", 0, 0, &numbers)
+	if err = cl.Parse([]string{"rm", "-f", "a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[0] != "a" || files[1] != "b" || files[2] != "c" {
+		t.Fatal("Unexpected variadic parameter value.")
+	}
+	// Min count.
+	if err = cl.Parse([]string{"rm", "-f"}); err == nil {
+		t.Fatal("Failed detecting missing variadic parameter arguments.")
+	}
+	// Max count.
+	if err = cl.Parse([]string{"rm", "a", "b", "c", "d"}); err == nil {
+		t.Fatal("Failed detecting extra variadic parameter arguments.")
+	}
+	if err = cl.Parse([]string{"sum", "0", "1", "2", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 3 || numbers[0] != 1 || numbers[2] != 3 {
+		t.Fatal("Unexpected variadic parameter value.")
+	}
+	if err = cl.Parse([]string{"sum", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"sum", "0", "1", "x"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid variadic parameter argument.")
+	}
+	var out = cl.Print()
+	if !strings.Contains(out, "<file>...") || !strings.Contains(out, "[numbers]...") {
+		t.Fatal("Variadic parameter not printed.")
+	}
+}
+
+// Test abbreviated command and long param names.
+func TestAbbreviate(t *testing.T) {
+	var executed string
+	var handler = func(ctx Context) error {
+		executed = ctx.Name()
+		return nil
+	}
+	var verbose string
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("install", "", handler).
+		MustAddParam("verbose", "v", "", false, &verbose).
+		MustAddParam("version", "", "", false, nil).
+		MustAddNegatableParam("color", "", "", nil)
+	cl.MustAddCommand("inspect", "", handler)
+	cl.MustAddCommand("remove", "", handler)
+	// Abbreviations are disabled by default.
+	if err = cl.Parse([]string{"rem"}); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Failed detecting abbreviated command in strict mode.")
+	}
+	cl.Abbreviate = true
+	if err = cl.Parse([]string{"rem"}); err != nil {
+		t.Fatal(err)
+	}
+	if executed != "remove" {
+		t.Fatal("Abbreviated command not executed.")
+	}
+	if err = cl.Parse([]string{"inst", "--verb", "1", "--no-c"}); err != nil {
+		t.Fatal(err)
+	}
+	if executed != "install" || verbose != "1" {
+		t.Fatal("Abbreviated command or param not parsed.")
+	}
+	if err = cl.Parse([]string{"ins"}); !errors.Is(err, ErrAmbiguous) {
+		t.Fatal("Failed detecting ambiguous command abbreviation.")
+	} else if !strings.Contains(err.Error(), "inspect, install") {
+		t.Fatal("Ambiguous command candidates not listed.")
+	}
+	if err = cl.Parse([]string{"install", "--ver"}); !errors.Is(err, ErrAmbiguous) {
+		t.Fatal("Failed detecting ambiguous param abbreviation.")
+	} else if !strings.Contains(err.Error(), "verbose, version") {
+		t.Fatal("Ambiguous param candidates not listed.")
+	}
+	if err = cl.Parse([]string{"install", "--foo"}); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Failed detecting non-existent param.")
+	}
+}
+
+// Test command and param aliases.
+func TestAliases(t *testing.T) {
+	var color bool
+	var cl = NewState()
+	var err error
+	var remove = cl.MustAddCommand("remove", "Remove things.", func(ctx Context) error {
+		if ctx.Name() != "remove" {
+			t.Fatal("Context name is not the canonical command name.")
+		}
+		if !ctx.Parsed("force") {
+			t.Fatal("Aliased param not parsed.")
+		}
+		return nil
+	})
+	cl.MustAddCommand("list", "", nil)
+	remove.MustAddParam("force", "f", "", false, nil).
+		MustAddNegatableParam("color", "", "", &color)
+	// Duplicate command aliases.
+	if err = cl.AliasCommand("remove", "list"); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting duplicate command alias.")
+	}
+	if err = cl.AliasCommand("remove", "rm", "rm"); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting duplicate command alias.")
+	}
+	if err = cl.AliasCommand("foo", "bar"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting alias of non-existent command.")
+	}
+	cl.MustAliasCommand("remove", "rm", "del")
+	if _, err = cl.AddCommand("rm", "", nil); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting command name duplicating an alias.")
+	}
+	if cl.CommandCount() != 2 {
+		t.Fatal("Aliases counted as commands.")
+	}
+	// Duplicate param aliases.
+	if err = remove.AliasParam("force", "color"); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting duplicate param alias.")
+	}
+	if err = remove.AliasParam("force", "no-color"); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting duplicate param alias.")
+	}
+	remove.MustAliasParam("force", "yes").
+		MustAliasParam("color", "colour")
+	if err = remove.AddParam("no-colour", "", "", false, nil); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting param name duplicating a negated alias.")
+	}
+	if err = cl.Parse([]string{"rm", "--yes", "--no-colour"}); err != nil {
+		t.Fatal(err)
+	}
+	if color {
+		t.Fatal("Negated param alias not parsed.")
+	}
+	if err = cl.Parse([]string{"del", "--force", "--colour"}); err != nil {
+		t.Fatal(err)
+	}
+	if !color {
+		t.Fatal("Param alias not parsed.")
+	}
+	cl.Abbreviate = true
+	if err = cl.Parse([]string{"rem", "--fo", "--col"}); err != nil {
+		t.Fatal(err)
+	}
+	var out = cl.Print()
+	if !strings.Contains(out, "remove, rm, del") || !strings.Contains(out, "[--force|--yes]") ||
+		!strings.Contains(out, "[--[no-]color|--[no-]colour]") {
+		t.Fatal("Aliases not printed.")
+	}
+}
+
+// Test param default values.
+func TestDefaults(t *testing.T) {
+	var port = 8080
+	var host string
+	var tags = []string{"a", "b"}
+	var cl = NewState()
+	var err error
+	var cmd = cl.MustAddCommand("serve", "", func(ctx Context) error {
+		if ctx.Default("port") != "8080" || ctx.Default("host") != "localhost" {
+			t.Fatal("Unexpected default values.")
+		}
+		return nil
+	}).
+		MustAddParam("port", "p", "", false, &port).
+		MustAddParam("host", "", "", false, &host).
+		MustAddRepeatedParam("tag", "", "", false, &tags).
+		MustAddParam("mode", "", "", false, nil)
+	// Default must convert.
+	cmd.MustSetDefault("port", "x")
+	if err = cl.Parse([]string{"serve"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid default value.")
+	}
+	cmd.MustSetDefault("port", "8080")
+	if err = cmd.SetDefault("foo", "x"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting default of non-existent param.")
+	}
+	cmd.MustSetDefault("host", "localhost").
+		MustSetDefault("mode", "fast")
+	if err = cl.Parse([]string{"serve", "--port", "80", "--host", "example.com", "--tag", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 80 || host != "example.com" || len(tags) != 1 || tags[0] != "c" {
+		t.Fatal("Unexpected values.")
+	}
+	// Defaults are restored on each parse.
+	if err = cl.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 || host != "localhost" || len(tags) != 2 || tags[0] != "a" {
+		t.Fatal("Default values not restored.")
+	}
+	var out = cl.Print()
+	if !strings.Contains(out, "(default: 8080)") || !strings.Contains(out, "(default: localhost)") ||
+		!strings.Contains(out, "(default: fast)") {
+		t.Fatal("Default values not printed.")
+	}
+}
+
+// Test reading param values from environment.
+func TestEnv(t *testing.T) {
+	var env = map[string]string{
+		"MYAPP_SERVE_PORT":    "8080",
+		"MYAPP_SERVE_VERBOSE": "true",
+		"MYAPP_SERVE_COLOR":   "false",
+		"LISTEN_HOST":         "example.com",
+		"MYAPP_SERVE_ROOT":    "/srv",
+	}
+	var lookup = func(key string) (value string, ok bool) {
+		value, ok = env[key]
+		return
+	}
+	var port int
+	var host string
+	var color bool
+	var root string
+	var cl = NewState()
+	var err error
+	var cmd = cl.MustAddCommand("serve", "", func(ctx Context) error {
+		if !ctx.Parsed("verbose") {
+			t.Fatal("Flag not parsed from environment.")
+		}
+		return nil
+	}).
+		MustAddParam("port", "p", "", true, &port).
+		MustAddParam("host", "", "", false, &host).
+		MustAddParam("verbose", "v", "", false, nil).
+		MustAddNegatableParam("color", "", "", &color).
+		MustAddRawParam("root", "", false, &root)
+	if err = cmd.SetEnv("host", ""); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting invalid environment variable name.")
+	}
+	if err = cmd.SetEnv("root", "ROOT"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting environment variable of a raw parameter.")
+	}
+	cmd.MustSetEnv("host", "HOST", "LISTEN_HOST")
+	cl.LookupEnv = lookup
+	// Environment is not read without prefix except for explicit names.
+	if err = cl.Parse([]string{"serve"}); err == nil {
+		t.Fatal("Failed detecting missing required parameter.")
+	}
+	cl.EnvPrefix = "myapp"
+	if err = cl.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 || host != "example.com" || color {
+		t.Fatal("Values not read from environment.")
+	}
+	if root != "" {
+		t.Fatal("Raw parameter read from environment.")
+	}
+	// Command line takes precedence.
+	if err = cl.Parse([]string{"serve", "--port", "80", "--color"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 80 || !color {
+		t.Fatal("Values from environment override command line.")
+	}
+	env["MYAPP_SERVE_PORT"] = "x"
+	if err = cl.Parse([]string{"serve"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid environment variable value.")
+	}
+	env["MYAPP_SERVE_PORT"] = "8080"
+	// Empty command params are read from environment if not matched.
+	var debug bool
+	env["MYAPP_DEBUG"] = "true"
+	cl.MustAddCommand("", "", nil).
+		MustAddNegatableParam("debug", "", "", &debug)
+	if err = cl.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if !debug {
+		t.Fatal("Empty command value not read from environment.")
+	}
+	var out = cl.Print()
+	if !strings.Contains(out, "(env: MYAPP_SERVE_PORT)") || !strings.Contains(out, "(env: HOST, LISTEN_HOST)") {
+		t.Fatal("Environment variables not printed.")
+	}
+	if strings.Contains(out, "MYAPP_SERVE_ROOT") {
+		t.Fatal("Environment variable of a raw parameter printed.")
+	}
+}
+
+// logLevel is a test Value.
+type logLevel int
+
+// Set implements Value.Set.
+func (l *logLevel) Set(s string) error {
+	switch s {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	case "error":
+		*l = 2
+	default:
+		return errors.New("invalid log level")
+	}
+	return nil
+}
+
+// String implements Value.String.
+func (l *logLevel) String() string { return [...]string{"debug", "info", "error"}[*l] }
+
+// byteSize is a test type converted by a registered Converter.
+type byteSize int64
+
+// mode is a test named string type.
+type mode string
+
+// hostPort is a test encoding.TextUnmarshaler.
+type hostPort struct {
+	host, port string
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (hp *hostPort) UnmarshalText(text []byte) error {
+	var i = strings.LastIndexByte(string(text), ':')
+	if i < 0 {
+		return errors.New("invalid host:port")
+	}
+	hp.host, hp.port = string(text[:i]), string(text[i+1:])
+	return nil
+}
+
+// Test Value interface, encoding.TextUnmarshaler and registered converters.
+func TestConverters(t *testing.T) {
+	var level logLevel
+	var size byteSize
+	var sizes []byteSize
+	var listen hostPort
+	var m mode
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", nil).
+		MustAddParam("level", "l", "", false, &level).
+		MustAddParam("mode", "", "", false, &m).
+		MustAddParam("listen", "", "", false, &listen).
+		MustAddParam("size", "s", "", false, &size).
+		MustAddRepeatedParam("sizes", "", "", false, &sizes)
+	if err = cl.Parse([]string{"foo", "--level", "error"}); err != nil {
+		t.Fatal(err)
+	}
+	if level != 2 {
+		t.Fatal("Value not set.")
+	}
+	if err = cl.Parse([]string{"foo", "--level", "trace"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid Value.")
+	}
+	// Named string type.
+	if err = cl.Parse([]string{"foo", "--mode", "fast"}); err != nil {
+		t.Fatal(err)
+	}
+	if m != "fast" {
+		t.Fatal("Named string type not converted.")
+	}
+	// encoding.TextUnmarshaler with a pointer receiver.
+	if err = cl.Parse([]string{"foo", "--listen", "localhost:80"}); err != nil {
+		t.Fatal(err)
+	}
+	if listen.host != "localhost" || listen.port != "80" {
+		t.Fatal("TextUnmarshaler not used.")
+	}
+	if err = cl.Parse([]string{"foo", "--listen", "localhost"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid TextUnmarshaler value.")
+	}
+	// Default conversion without a registered converter.
+	if err = cl.Parse([]string{"foo", "--size", "1K"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid value.")
+	}
+	cl.RegisterConverter(reflect.TypeOf(size), func(s string) (interface{}, error) {
+		var n, err = strconv.ParseInt(strings.TrimSuffix(s, "K"), 10, 64)
+		if strings.HasSuffix(s, "K") {
+			n *= 1024
+		}
+		return byteSize(n), err
+	})
+	if err = cl.Parse([]string{"foo", "--size", "1K", "--sizes", "2K", "--sizes", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if size != 1024 || len(sizes) != 2 || sizes[0] != 2048 || sizes[1] != 3 {
+		t.Fatal("Registered converter not used.")
+	}
+	// Converter must return an assignable value.
+	cl.RegisterConverter(reflect.TypeOf(size), func(s string) (interface{}, error) {
+		return s, nil
+	})
+	if err = cl.Parse([]string{"foo", "--size", "1K"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid converter result.")
+	}
+	cl.RegisterConverter(reflect.TypeOf(size), nil)
+	if err = cl.Parse([]string{"foo", "--size", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	// Defaults are converted using registered converters.
+	cl.MustGetCommand("foo").MustSetDefault("size", "2K")
+	if err = cl.Parse([]string{"foo"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid default value.")
+	}
+	cl.RegisterConverter(reflect.TypeOf(size), func(s string) (interface{}, error) {
+		var n, err = strconv.ParseInt(strings.TrimSuffix(s, "K"), 10, 64)
+		return byteSize(n * 1024), err
+	})
+	if err = cl.Parse([]string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if size != 2048 {
+		t.Fatal("Registered converter not used for default value.")
+	}
+}
+
+// Test parameter choices.
+func TestChoices(t *testing.T) {
+	var format string
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", nil).
+		MustAddParam("format", "f", "", false, &format).
+		MustAddParam("verbose", "v", "", false, nil).
+		MustSetChoices("format", "json", "yaml", "table").
+		MustAddRawParam("output", "", false, nil).
+		MustSetChoices("output", "stdout", "stderr")
+	var params = cl.MustGetCommand("foo").Parameters
+	if err = params.SetChoices("verbose", "a"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting choices on a param without value.")
+	}
+	if err = params.SetChoices("bar", "a"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting choices on nonexistent param.")
+	}
+	if choices := params.Choices("format"); len(choices) != 3 || choices[2] != "table" {
+		t.Fatal("Failed retrieving choices.")
+	}
+	if err = cl.Parse([]string{"foo", "--format", "yaml", "stderr"}); err != nil {
+		t.Fatal(err)
+	}
+	if format != "yaml" {
+		t.Fatal("Choice not set.")
+	}
+	if err = cl.Parse([]string{"foo", "--format", "jsn"}); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatal("Failed detecting invalid choice.")
+	}
+	if !strings.Contains(err.Error(), "json, yaml, table") || !strings.Contains(err.Error(), "did you mean 'json'") {
+		t.Fatal("Invalid choice error does not list choices and suggestion.")
+	}
+	if err = cl.Parse([]string{"foo", "--format", "xml"}); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatal("Failed detecting invalid choice.")
+	}
+	if strings.Contains(err.Error(), "did you mean") {
+		t.Fatal("Suggested a distant choice.")
+	}
+	if err = cl.Parse([]string{"foo", "stdin"}); !errors.Is(err, ErrInvalidChoice) {
+		t.Fatal("Failed detecting invalid raw choice.")
+	}
+	if !strings.Contains(cl.Print(), "(choices: json, yaml, table)") {
+		t.Fatal("Choices not printed.")
+	}
+	params.MustSetChoices("format")
+	if err = cl.Parse([]string{"foo", "--format", "xml"}); err != nil {
+		t.Fatal(err)
+	}
+	// Defaults must be one of choices.
+	var mode = "slow"
+	var bar = cl.MustAddCommand("bar", "", nil).
+		MustAddParam("mode", "", "", false, &mode).
+		MustAddParam("level", "", "", false, new(int))
+	if err = bar.SetChoices("mode", "fast", "normal"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting default value not in choices.")
+	}
+	if bar.Choices("mode") != nil {
+		t.Fatal("Choices set after failure.")
+	}
+	bar.MustSetChoices("level", "1", "2")
+	if err = bar.SetDefault("level", "3"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting default value not in choices.")
+	}
+	bar.MustSetDefault("level", "2").
+		MustSetDefault("mode", "fast").
+		MustSetChoices("mode", "fast", "normal")
+}
+
+// Test parameter and command validators.
+func TestValidators(t *testing.T) {
+	var port int
+	var called []string
+	var cl = NewState()
+	var err error
+	var handler = func(ctx Context) error {
+		called = append(called, ctx.Name())
+		return nil
+	}
+	cl.MustAddCommand("foo", "", handler).
+		MustAddParam("port", "p", "", false, &port).
+		MustAddValidator("port", func(v interface{}) error {
+			if v.(int) < 1 || v.(int) > 65535 {
+				return errors.New("port out of range")
+			}
+			return nil
+		}).
+		MustAddCommand("bar", "", handler).
+		MustAddParam("name", "n", "", false, nil).
+		MustAddRawParam("file", "", false, nil).
+		MustAddValidator("file", func(v interface{}) error {
+			if !strings.HasSuffix(v.(string), ".txt") {
+				return errors.New("not a text file")
+			}
+			return nil
+		}).
+		SetValidator(func(ctx Context) error {
+			if ctx.Parsed("name") && !ctx.Parsed("file") {
+				return errors.New("name requires file")
+			}
+			return nil
+		})
+	var params = cl.MustGetCommand("foo").Parameters
+	if err = params.AddValidator("bar", func(interface{}) error { return nil }); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting validator on nonexistent param.")
+	}
+	if err = params.AddValidator("port", nil); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting nil validator.")
+	}
+	if err = cl.Parse([]string{"foo", "-p", "80", "bar", "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(called) != 2 {
+		t.Fatal("Handlers not called.")
+	}
+	var tests = [][]string{
+		{"foo", "-p", "0", "bar", "a.txt"},
+		{"foo", "-p", "80", "bar", "a.bin"},
+		{"foo", "-p", "80", "bar", "-n"},
+	}
+	for _, args := range tests {
+		called = nil
+		if err = cl.Parse(args); !errors.Is(err, ErrValidate) {
+			t.Fatal("Failed detecting invalid value.")
+		}
+		if len(called) != 0 {
+			t.Fatal("Handlers called after failed validation.")
+		}
+	}
+	// Validators of params that were not parsed are not run.
+	if err = cl.Parse([]string{"foo", "bar"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test Go values are written only if parse succeeds.
+func TestAtomic(t *testing.T) {
+	var name = "default"
+	var count int
+	var tags []string
+	var port int
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", func(ctx Context) error {
+		if name != "bob" {
+			t.Fatal("Values not written before handler.")
+		}
+		return nil
+	}).
+		MustAddParam("name", "n", "", false, &name).
+		MustAddCounterParam("verbose", "v", "", &count).
+		MustAddRepeatedParam("tag", "t", "", false, &tags).
+		MustAddParam("port", "p", "", false, &port).
+		MustAddValidator("port", func(v interface{}) error {
+			if v.(int) < 0 {
+				return errors.New("negative port")
+			}
+			return nil
+		})
+	if err = cl.Parse([]string{"foo", "-n", "alice", "-vv", "-t", "a", "-p", "x"}); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid value.")
+	}
+	if name != "default" || count != 0 || tags != nil || port != 0 {
+		t.Fatal("Values written on failed parse.")
+	}
+	if err = cl.Parse([]string{"foo", "-n", "alice", "-t", "a", "-p", "-1"}); !errors.Is(err, ErrValidate) {
+		t.Fatal("Failed detecting invalid value.")
+	}
+	if name != "default" || tags != nil || port != 0 {
+		t.Fatal("Values written on failed validation.")
+	}
+	if err = cl.Parse([]string{"foo", "-n", "bob", "-vv", "-t", "a", "-p", "80"}); err != nil {
+		t.Fatal(err)
+	}
+	if name != "bob" || count != 2 || len(tags) != 1 || port != 80 {
+		t.Fatal("Values not written.")
+	}
+	if err = cl.Parse([]string{"foo", "-n", "alice", "-x"}); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Failed detecting invalid parameter.")
+	}
+	if name != "bob" || count != 2 || len(tags) != 1 || port != 80 {
+		t.Fatal("Values written on failed parse.")
+	}
+}
+
 // Test registered raw params.
 func TestRegisteredRaw(t *testing.T) {
 	var foo = func(ctx Context) error {
@@ -577,15 +1633,16 @@ func ExampleState() {
 		panic(err)
 	}
 	fmt.Println(cl.Print())
-	// Output: Hello from 'baz' Command.
-
-	// [--verbose]     -v      Verbose output.
-
-	// foo     Do the foo.
-	// 		<--bar> -r      (string)        Enable bar.
-
-	// 		baz     Do the baz.
-	// 				[bat]   Enable bat.
+	// Output:
+	// Hello from 'baz' Command.
+	//
+	// 	[--verbose]	-v	Verbose output.
+	//
+	// foo	Do the foo.
+	// 	<--bar>	-r	(string)	Enable bar.
+	//
+	// 	baz	Do the baz.
+	// 		[bat]	Enable bat.
 }
 
 func TestRepeat(t *testing.T) {
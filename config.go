@@ -0,0 +1,298 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ErrConfig is the base configuration file error.
+var ErrConfig = fmt.Errorf("%w: config", ErrParse)
+
+// configValues maps a Parameter to its' values loaded from configuration.
+type configValues map[*Parameter][]string
+
+// LoadConfigFile loads a configuration file. Format is selected by file
+// extension; ".json" files are loaded using LoadJSON and any other using
+// LoadINI. See LoadJSON and LoadINI for details.
+func (state *State) LoadConfigFile(filename string) error {
+	var file, err = os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	defer file.Close()
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return state.LoadJSON(file)
+	}
+	return state.LoadINI(file)
+}
+
+// LoadJSON loads parameter values from a JSON configuration read from r.
+//
+// Configuration is an object whose keys are names of Commands or long names
+// of Parameters. A key whose value is an object addresses a Command and its'
+// value addresses Command's Parameters and sub Commands, recursively. A key
+// whose value is a string, number or bool addresses a Parameter. Arrays can
+// be given to Parameters that can be specified multiple times. Keys in the
+// root object that are not objects address Parameters of the empty Command.
+// e.g. {"verbose": true, "serve": {"port": 8080, "tag": ["a", "b"]}}
+//
+// Values are validated against registered Commands and Parameters when
+// loaded and are applied during Parse to Parameters of Commands matched from
+// command line and of the empty Command that were not specified on command
+// line or in environment. Raw Parameters cannot be configured.
+// Values satisfy the required rule. Values of multiple loads are merged. If
+// an error occurs it is ErrConfig or a descendant, ErrConvert or a
+// descendant, and no values are loaded.
+func (state *State) LoadJSON(r io.Reader) error {
+	var decoder = json.NewDecoder(r)
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	var values = make(configValues)
+	var params *Parameters
+	if cmd, ok := state.GetCommand(""); ok {
+		params = cmd.Parameters
+	}
+	var err error
+	if err = loadJSON(values, state.Commands, params, root, nil); err != nil {
+		return err
+	}
+	if err = values.check(state.converters); err != nil {
+		return err
+	}
+	state.mergeConfig(values)
+	return nil
+}
+
+// LoadINI loads parameter values from an INI configuration read from r.
+//
+// Section names are dot separated Command paths, e.g. "[serve]" or
+// "[remote.add]". Keys are long names of Parameters of the Command addressed
+// by the section, or of the empty Command if they precede any sections.
+// Repeated keys give multiple values to Parameters that can be specified
+// multiple times. Lines starting with ";" or "#" are comments and double
+// quotes around values are removed.
+// e.g.
+//
+//	verbose = true
+//	[serve]
+//	port = 8080
+//
+// Other rules of LoadJSON apply.
+func (state *State) LoadINI(r io.Reader) error {
+	var values = make(configValues)
+	var scanner = bufio.NewScanner(r)
+	var params *Parameters
+	var section, line, key, value string
+	var cmd *Command
+	var lineno, i int
+	var err error
+	if cmd, _ = state.GetCommand(""); cmd != nil {
+		params = cmd.Parameters
+	}
+	for scanner.Scan() {
+		lineno++
+		if line = strings.TrimSpace(scanner.Text()); line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return fmt.Errorf("%w: line %d: invalid section", ErrConfig, lineno)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if cmd, err = findConfigCommand(state.Commands, strings.Split(section, ".")); err != nil {
+				return err
+			}
+			params = cmd.Parameters
+			continue
+		}
+		if i = strings.IndexByte(line, '='); i < 0 {
+			return fmt.Errorf("%w: line %d: invalid key", ErrConfig, lineno)
+		}
+		key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		if params == nil {
+			return fmt.Errorf("%w: unknown key '%s'", ErrConfig, key)
+		}
+		if err = values.add(params, key, section, value); err != nil {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfig, err)
+	}
+	if err = values.check(state.converters); err != nil {
+		return err
+	}
+	state.mergeConfig(values)
+	return nil
+}
+
+// loadJSON loads values from a JSON object obj into values addressing
+// commands in commands and Parameters in params. Path is the path of obj,
+// used in error messages.
+func loadJSON(values configValues, commands *Commands, params *Parameters, obj map[string]interface{}, path []string) error {
+	var key, name string
+	var value interface{}
+	var err error
+	for key, value = range obj {
+		name = strings.Join(appendPath(path, key), ".")
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var cmd *Command
+			if cmd, err = findConfigCommand(commands, []string{key}); err != nil {
+				return fmt.Errorf("%w: unknown key '%s'", ErrConfig, name)
+			}
+			if err = loadJSON(values, cmd.Commands, cmd.Parameters, v, appendPath(path, key)); err != nil {
+				return err
+			}
+		case []interface{}:
+			if params == nil {
+				return fmt.Errorf("%w: unknown key '%s'", ErrConfig, name)
+			}
+			for _, elem := range v {
+				var s string
+				if s, err = jsonScalar(elem, name); err != nil {
+					return err
+				}
+				if err = values.add(params, key, strings.Join(path, "."), s); err != nil {
+					return err
+				}
+			}
+		default:
+			if params == nil {
+				return fmt.Errorf("%w: unknown key '%s'", ErrConfig, name)
+			}
+			var s string
+			if s, err = jsonScalar(v, name); err != nil {
+				return err
+			}
+			if err = values.add(params, key, strings.Join(path, "."), s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonScalar returns a JSON scalar value v as string or an error if v is not
+// a scalar. Name is the key of the value, used in error messages.
+func jsonScalar(v interface{}, name string) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return fmt.Sprint(value), nil
+	}
+	return "", fmt.Errorf("%w: invalid value of key '%s'", ErrConfig, name)
+}
+
+// findConfigCommand returns a command from commands by path of names or
+// aliases or an error if not found.
+func findConfigCommand(commands *Commands, path []string) (*Command, error) {
+	var cmd *Command
+	var ok bool
+	for _, name := range path {
+		if name == "" {
+			break
+		}
+		if cmd, ok = commands.GetCommand(name); !ok {
+			return nil, fmt.Errorf("%w: unknown command '%s'", ErrConfig, strings.Join(path, "."))
+		}
+		commands = cmd.Commands
+	}
+	if cmd == nil {
+		return nil, fmt.Errorf("%w: unknown command '%s'", ErrConfig, strings.Join(path, "."))
+	}
+	return cmd, nil
+}
+
+// add adds a value to a Parameter in params addressed by long name. Section
+// is the path of the Command, used in error messages. Negated names of
+// negatable Parameters and raw Parameters are not valid keys.
+func (cv configValues) add(params *Parameters, long, section, value string) error {
+	var key = long
+	if section != "" {
+		key = section + "." + long
+	}
+	var param, ok = params.longparams[long]
+	if !ok || param.negated(long) {
+		return fmt.Errorf("%w: unknown key '%s'", ErrConfig, key)
+	}
+	if param.raw {
+		return fmt.Errorf("%w: raw parameter key '%s'", ErrConfig, key)
+	}
+	if len(cv[param]) > 0 && !param.multiple() {
+		return fmt.Errorf("%w: duplicate key '%s'", ErrConfig, key)
+	}
+	cv[param] = append(cv[param], value)
+	return nil
+}
+
+// check checks that all values can be applied to their Parameters by
+// applying them to a copy of each Parameter with a new Go value.
+func (cv configValues) check(conv converters) error {
+	var param *Parameter
+	var values []string
+	for param, values = range cv {
+		var scratch = *param
+		scratch.parsed = false
+		scratch.rawvalues = nil
+		scratch.count = 0
+		scratch.staged = reflect.Value{}
+		if param.value != nil {
+			scratch.value = reflect.New(reflect.Indirect(reflect.ValueOf(param.value)).Type()).Interface()
+		}
+		if err := scratch.apply(values, conv); err != nil {
+			return fmt.Errorf("%w: config parameter '%s'", err, param.name)
+		}
+	}
+	return nil
+}
+
+// mergeConfig merges values into State configuration values.
+func (state *State) mergeConfig(values configValues) {
+	if state.config == nil {
+		state.config = make(configValues)
+	}
+	for param, v := range values {
+		state.config[param] = v
+	}
+}
+
+// parseConfig sets values of Parameters not parsed from command line
+// arguments or environment from loaded configuration values.
+func (p *Parameters) parseConfig(state *State) error {
+	var param *Parameter
+	var values []string
+	var ok bool
+	for _, long := range p.longindexes {
+		if param = p.longparams[long]; param.parsed {
+			continue
+		}
+		if values, ok = state.config[param]; !ok {
+			continue
+		}
+		if err := param.apply(values, state.converters); err != nil {
+			return fmt.Errorf("%w: config value '%s'", err, long)
+		}
+		param.mark(SourceConfig, -1)
+	}
+	return nil
+}
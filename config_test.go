@@ -0,0 +1,147 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newConfigState returns a State used by config tests.
+func newConfigState(verbose *bool, port *int, host *string, tags *[]string) *State {
+	var state = NewState()
+	state.MustAddCommand("", "", nil).
+		MustAddNegatableParam("verbose", "v", "", verbose).
+		MustAddCounterParam("debug", "d", "", nil)
+	state.MustAddCommand("serve", "", nil).
+		MustAddParam("port", "p", "", true, port).
+		MustAddParam("host", "", "", false, host).
+		MustAddRepeatedParam("tag", "t", "", false, tags).
+		MustAddCommand("sub", "", nil).
+		MustAddParam("name", "", "", false, new(string))
+	state.MustAddCommand("cat", "", nil).
+		MustAddRawParam("file", "", false, nil)
+	return state
+}
+
+// Test loading JSON configuration.
+func TestLoadJSON(t *testing.T) {
+	var verbose bool
+	var port int
+	var host string
+	var tags []string
+	var state = newConfigState(&verbose, &port, &host, &tags)
+	var err error
+	// Unknown command.
+	if err = state.LoadJSON(strings.NewReader(`{"foo": {"port": 1}}`)); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting unknown command.")
+	}
+	// Unknown parameter.
+	if err = state.LoadJSON(strings.NewReader(`{"serve": {"foo": 1}}`)); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting unknown parameter.")
+	}
+	// Non-repeated parameter given multiple values.
+	if err = state.LoadJSON(strings.NewReader(`{"serve": {"port": [1, 2]}}`)); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting multiple values of a non-repeated parameter.")
+	}
+	// Invalid value.
+	if err = state.LoadJSON(strings.NewReader(`{"serve": {"port": "x"}}`)); !errors.Is(err, ErrConvert) {
+		t.Fatal("Failed detecting invalid value.")
+	}
+	var config = `{
+		"verbose": false,
+		"serve": {
+			"port": 8080,
+			"host": "localhost",
+			"tag": ["a", "b"],
+			"sub": {"name": "foo"}
+		}
+	}`
+	if err = state.LoadJSON(strings.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+	// Config satisfies required.
+	if err = state.Parse([]string{"-d", "serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if verbose || port != 8080 || host != "localhost" || len(tags) != 2 || tags[1] != "b" {
+		t.Fatal("Values not loaded from config.")
+	}
+	// Command line takes precedence.
+	if err = state.Parse([]string{"-v", "serve", "--port", "80", "-t", "c", "sub"}); err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || port != 80 || host != "localhost" || len(tags) != 1 || tags[0] != "c" {
+		t.Fatal("Config values override command line.")
+	}
+	var cmd = state.MustGetCommand("serve").MustGetCommand("sub")
+	if !cmd.Parameters.longparams["name"].parsed || cmd.Parameters.longparams["name"].rawvalue != "foo" {
+		t.Fatal("Sub command value not loaded from config.")
+	}
+}
+
+// Test loading INI configuration.
+func TestLoadINI(t *testing.T) {
+	var verbose bool
+	var port int
+	var host string
+	var tags []string
+	var state = newConfigState(&verbose, &port, &host, &tags)
+	var err error
+	// Unknown section.
+	if err = state.LoadINI(strings.NewReader("[foo]\nport = 1\n")); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting unknown section.")
+	}
+	// Unknown key.
+	if err = state.LoadINI(strings.NewReader("[serve]\nfoo = 1\n")); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting unknown key.")
+	}
+	// Duplicate key.
+	if err = state.LoadINI(strings.NewReader("[serve]\nport = 1\nport = 2\n")); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting duplicate key.")
+	}
+	// Negated names are not keys.
+	if err = state.LoadINI(strings.NewReader("no-verbose = true\n")); !errors.Is(err, ErrConfig) {
+		t.Fatal("Failed detecting negated name as key.")
+	}
+	// Raw params are not keys.
+	if err = state.LoadINI(strings.NewReader("[cat]\nfile = foo\n")); !errors.Is(err, ErrParse) {
+		t.Fatal("Failed detecting raw parameter as key.")
+	}
+	var config = `
+; Comment.
+verbose = false
+
+# Comment.
+[serve]
+port = 8080
+host = "localhost"
+tag = a
+tag = b
+
+[serve.sub]
+name = foo
+`
+	if err = state.LoadINI(strings.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.Parse([]string{"-d", "serve", "sub"}); err != nil {
+		t.Fatal(err)
+	}
+	if verbose || port != 8080 || host != "localhost" || len(tags) != 2 || tags[0] != "a" {
+		t.Fatal("Values not loaded from config.")
+	}
+	// Empty command values are loaded if it was not matched.
+	if err = state.LoadINI(strings.NewReader("verbose = true\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if !verbose {
+		t.Fatal("Empty command value not loaded from config.")
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrConstraint is returned when parsed parameters violate a constraint.
+var ErrConstraint = fmt.Errorf("%w: constraint", ErrParse)
+
+// constraintKind defines the kind of a relationship between Parameters.
+type constraintKind int
+
+const (
+	// exclusive allows at most one of params to be parsed.
+	exclusive constraintKind = iota
+	// together requires all or none of params to be parsed.
+	together
+	// oneOf requires at least one of params to be parsed.
+	oneOf
+	// requires requires all params following the first if first is parsed.
+	requires
+)
+
+// constraint defines a relationship between Parameters by long names.
+type constraint struct {
+	kind  constraintKind
+	names []string
+}
+
+// AddExclusive registers a constraint that at most one of params registered
+// under specified long names can be specified. If an error occurs it is
+// ErrRegister or a descendant.
+func (p *Parameters) AddExclusive(longs ...string) error {
+	return p.addConstraint(exclusive, longs)
+}
+
+// MustAddExclusive is like AddExclusive except the function panics on error.
+// Returns a Command that the constraint was added to.
+func (p *Parameters) MustAddExclusive(longs ...string) *Command {
+	var err error
+	if err = p.AddExclusive(longs...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddTogether registers a constraint that either all or none of params
+// registered under specified long names must be specified. If an error
+// occurs it is ErrRegister or a descendant.
+func (p *Parameters) AddTogether(longs ...string) error {
+	return p.addConstraint(together, longs)
+}
+
+// MustAddTogether is like AddTogether except the function panics on error.
+// Returns a Command that the constraint was added to.
+func (p *Parameters) MustAddTogether(longs ...string) *Command {
+	var err error
+	if err = p.AddTogether(longs...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddOneOf registers a constraint that at least one of params registered
+// under specified long names must be specified. If an error occurs it is
+// ErrRegister or a descendant.
+func (p *Parameters) AddOneOf(longs ...string) error {
+	return p.addConstraint(oneOf, longs)
+}
+
+// MustAddOneOf is like AddOneOf except the function panics on error.
+// Returns a Command that the constraint was added to.
+func (p *Parameters) MustAddOneOf(longs ...string) *Command {
+	var err error
+	if err = p.AddOneOf(longs...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// AddRequires registers a constraint that if a param registered under
+// specified long name is specified, params registered under names must be
+// specified as well. If an error occurs it is ErrRegister or a descendant.
+func (p *Parameters) AddRequires(long string, names ...string) error {
+	return p.addConstraint(requires, append([]string{long}, names...))
+}
+
+// MustAddRequires is like AddRequires except the function panics on error.
+// Returns a Command that the constraint was added to.
+func (p *Parameters) MustAddRequires(long string, names ...string) *Command {
+	var err error
+	if err = p.AddRequires(long, names...); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// addConstraint registers a constraint of kind between at least two params
+// registered under specified unique long names.
+func (p *Parameters) addConstraint(kind constraintKind, longs []string) error {
+	if len(longs) < 2 {
+		return fmt.Errorf("%w: constraint requires at least 2 parameters", ErrRegister)
+	}
+	var set = make(map[string]bool)
+	for _, long := range longs {
+		if param, ok := p.longparams[long]; !ok || param.name != long {
+			return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+		}
+		if set[long] {
+			return fmt.Errorf("%w: parameter '%s' in constraint", ErrDuplicate, long)
+		}
+		set[long] = true
+	}
+	p.constraints = append(p.constraints, constraint{kind, append([]string(nil), longs...)})
+	return nil
+}
+
+// checkConstraints returns ErrConstraint describing the first violated
+// constraint in order of registration or nil if none are violated.
+func (p *Parameters) checkConstraints() error {
+	var parsed, missing []string
+	var c constraint
+	var long string
+	for _, c = range p.constraints {
+		parsed, missing = nil, nil
+		for _, long = range c.names {
+			if p.longparams[long].parsed {
+				parsed = append(parsed, long)
+			} else {
+				missing = append(missing, long)
+			}
+		}
+		switch c.kind {
+		case exclusive:
+			if len(parsed) > 1 {
+				return fmt.Errorf("%w: parameters '%s' are mutually exclusive", ErrConstraint, strings.Join(parsed, "', '"))
+			}
+		case together:
+			if len(parsed) > 0 && len(missing) > 0 {
+				return fmt.Errorf("%w: parameters '%s' must be specified together, missing '%s'", ErrConstraint, strings.Join(c.names, "', '"), strings.Join(missing, "', '"))
+			}
+		case oneOf:
+			if len(parsed) == 0 {
+				return fmt.Errorf("%w: at least one of parameters '%s' must be specified", ErrConstraint, strings.Join(c.names, "', '"))
+			}
+		case requires:
+			if p.longparams[c.names[0]].parsed && len(missing) > 0 {
+				return fmt.Errorf("%w: parameter '%s' requires '%s'", ErrConstraint, c.names[0], strings.Join(missing, "', '"))
+			}
+		}
+	}
+	return nil
+}
+
+// print writes the constraint description to sb using param names as
+// specified on command line.
+func (c constraint) print(sb *strings.Builder, params *Parameters) {
+	var names = make([]string, 0, len(c.names))
+	for _, long := range c.names {
+		if !params.longparams[long].raw {
+			long = "--" + long
+		}
+		names = append(names, long)
+	}
+	switch c.kind {
+	case exclusive:
+		sb.WriteString("(mutually exclusive: ")
+	case together:
+		sb.WriteString("(required together: ")
+	case oneOf:
+		sb.WriteString("(at least one of: ")
+	case requires:
+		sb.WriteString("(")
+		sb.WriteString(names[0])
+		sb.WriteString(" requires: ")
+		names = names[1:]
+	}
+	sb.WriteString(strings.Join(names, ", "))
+	sb.WriteRune(')')
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Test parameter constraints.
+func TestConstraints(t *testing.T) {
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("foo", "", nil).
+		MustAddParam("json", "", "", false, nil).
+		MustAddParam("yaml", "", "", false, nil).
+		MustAddParam("user", "", "", false, new(string)).
+		MustAddParam("password", "", "", false, new(string)).
+		MustAddParam("tls", "", "", false, nil).
+		MustAddParam("cert", "", "", false, new(string)).
+		MustAddParam("key", "", "", false, new(string)).
+		MustAddExclusive("json", "yaml").
+		MustAddTogether("user", "password").
+		MustAddOneOf("json", "yaml", "tls").
+		MustAddRequires("tls", "cert", "key")
+	var params = cl.MustGetCommand("foo").Parameters
+	if err = params.AddExclusive("json"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting single parameter constraint.")
+	}
+	if err = params.AddExclusive("json", "bar"); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting nonexistent parameter in constraint.")
+	}
+	if err = params.AddOneOf("json", "json"); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting duplicate parameter in constraint.")
+	}
+	var tests = []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"foo", "--json"}, ""},
+		{[]string{"foo", "--json", "--yaml"}, "mutually exclusive"},
+		{[]string{"foo", "--json", "--user", "bob"}, "missing 'password'"},
+		{[]string{"foo", "--json", "--user", "bob", "--password", "x"}, ""},
+		{[]string{"foo", "--user", "bob", "--password", "x"}, "at least one of"},
+		{[]string{"foo", "--tls", "--cert", "a"}, "'tls' requires 'key'"},
+		{[]string{"foo", "--tls", "--cert", "a", "--key", "b"}, ""},
+		{[]string{"foo", "--yaml", "--cert", "a"}, ""},
+	}
+	for _, test := range tests {
+		err = cl.Parse(test.args)
+		if test.err == "" {
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrConstraint) || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("Failed detecting constraint violation: %v: %v", test.args, err)
+		}
+	}
+	var text = cl.Print()
+	for _, s := range []string{
+		"(mutually exclusive: --json, --yaml)",
+		"(required together: --user, --password)",
+		"(at least one of: --json, --yaml, --tls)",
+		"(--tls requires: --cert, --key)",
+	} {
+		if !strings.Contains(text, s) {
+			t.Fatalf("Constraint not printed: %s", s)
+		}
+	}
+}
+
+// Test empty command params set from environment are checked and validated
+// when the empty command is not matched.
+func TestRootConstraints(t *testing.T) {
+	var env = make(map[string]string)
+	var cl = NewState()
+	var err error
+	cl.EnvPrefix = "myapp"
+	cl.LookupEnv = func(key string) (value string, ok bool) {
+		value, ok = env[key]
+		return
+	}
+	cl.MustAddCommand("", "", nil).
+		MustAddParam("user", "", "", false, new(string)).
+		MustAddParam("password", "", "", false, new(string)).
+		MustAddParam("token", "", "", true, new(string)).
+		MustAddTogether("user", "password").
+		MustAddValidator("user", func(value interface{}) error {
+			if value.(string) == "root" {
+				return errors.New("root not allowed")
+			}
+			return nil
+		})
+	cl.MustAddCommand("serve", "", nil)
+	if err = cl.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	env["MYAPP_USER"] = "bob"
+	if err = cl.Parse([]string{"serve"}); !errors.Is(err, ErrParse) || !strings.Contains(err.Error(), "required parameter 'token'") {
+		t.Fatalf("Failed detecting missing required empty command parameter: %v", err)
+	}
+	env["MYAPP_TOKEN"] = "x"
+	if err = cl.Parse([]string{"serve"}); !errors.Is(err, ErrConstraint) {
+		t.Fatalf("Failed detecting empty command constraint violation: %v", err)
+	}
+	env["MYAPP_PASSWORD"] = "x"
+	if err = cl.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	env["MYAPP_USER"] = "root"
+	if err = cl.Parse([]string{"serve"}); !errors.Is(err, ErrValidate) {
+		t.Fatalf("Failed validating empty command parameter: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package gen generates Go source from commandline definitions.
+//
+// It is a separate package so that programs using commandline do not link
+// the code generator.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vedranvuk/commandline"
+)
+
+// handlerDef is a Command definition used when generating handlers.
+type handlerDef struct {
+	// cmd is the Command.
+	cmd *commandline.Command
+	// path are names of the Command and its' parents.
+	path []string
+	// name is the Go identifier of the Command.
+	name string
+}
+
+// Handlers writes Go source of package pkg to w that defines typed
+// handlers of all Commands in commands. For each Command it defines:
+//
+//   - a Context type named after the Command path, e.g. "RemoteAddContext",
+//     that embeds commandline.Context and has a typed accessor method for
+//     each Param named after the Param, e.g. "Port() int",
+//   - a method of the Handlers interface, e.g.
+//     "RemoteAdd(RemoteAddContext) error",
+//   - a stub of the method on UnimplementedHandlers that returns
+//     commandline.ErrNotImplemented.
+//
+// Generated SetHandlers function sets the handler of each Command to call the
+// method of a Handlers implementation.
+//
+// Generated file is to be regenerated when Commands change and not edited.
+// Handlers are implemented in a separate file by a type that embeds
+// UnimplementedHandlers so that handlers of new Commands are stubs until
+// implemented, e.g.:
+//
+//	type handlers struct{ UnimplementedHandlers }
+//
+//	func (h *handlers) Serve(ctx ServeContext) error { ... ctx.Port() ... }
+//
+// Usually invoked from a program run by go:generate that builds the same
+// Commands. Accessor of a Param of a type declared in package pkg uses the
+// type unqualified, other named types are imported.
+func Handlers(w io.Writer, pkg string, commands *commandline.Commands) error {
+	var defs []handlerDef
+	var used = map[string]bool{"Handlers": true, "UnimplementedHandlers": true, "SetHandlers": true}
+	collectHandlers(&defs, commands, nil, used)
+	if len(defs) == 0 {
+		return fmt.Errorf("%w: no commands", commandline.ErrRegister)
+	}
+	var imports = map[string]bool{"github.com/vedranvuk/commandline": true}
+	var body = &bytes.Buffer{}
+	var def handlerDef
+	for _, def = range defs {
+		writeContext(body, def, pkg, imports)
+	}
+	fmt.Fprintf(body, "// Handlers is the interface to handlers of Commands.\n")
+	fmt.Fprintf(body, "type Handlers interface {\n")
+	for _, def = range defs {
+		fmt.Fprintf(body, "// %s handles the %s.\n", def.name, commandTitle(def.path))
+		fmt.Fprintf(body, "%s(%sContext) error\n", def.name, def.name)
+	}
+	fmt.Fprintf(body, "}\n\n")
+	fmt.Fprintf(body, "// UnimplementedHandlers implements Handlers with stubs that return\n")
+	fmt.Fprintf(body, "// commandline.ErrNotImplemented. Embed it in Handlers implementations.\n")
+	fmt.Fprintf(body, "type UnimplementedHandlers struct{}\n\n")
+	for _, def = range defs {
+		fmt.Fprintf(body, "// %s implements Handlers.%s.\n", def.name, def.name)
+		fmt.Fprintf(body, "func (UnimplementedHandlers) %s(%sContext) error { return commandline.ErrNotImplemented }\n\n", def.name, def.name)
+	}
+	fmt.Fprintf(body, "// SetHandlers sets handlers of Commands in commands to methods of h.\n")
+	fmt.Fprintf(body, "// Commands must be defined as when generated.\n")
+	fmt.Fprintf(body, "func SetHandlers(commands *commandline.Commands, h Handlers) {\n")
+	for _, def = range defs {
+		fmt.Fprintf(body, "commands")
+		for _, name := range def.path {
+			fmt.Fprintf(body, ".MustGetCommand(%s)", strconv.Quote(name))
+		}
+		fmt.Fprintf(body, ".SetHandler(func(ctx commandline.Context) error { return h.%s(%sContext{ctx}) })\n", def.name, def.name)
+	}
+	fmt.Fprintf(body, "}\n")
+
+	var buf = &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by commandline/gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	var paths []string
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fmt.Fprintf(buf, "import (\n")
+	for _, path := range paths {
+		fmt.Fprintf(buf, "%s\n", strconv.Quote(path))
+	}
+	fmt.Fprintf(buf, ")\n\n")
+	buf.Write(body.Bytes())
+	var src, err = format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("%w: format generated source: %v", commandline.ErrCommandline, err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// collectHandlers recursively appends definitions of commands with parent
+// path to defs. Used are Go identifiers already defined.
+func collectHandlers(defs *[]handlerDef, commands *commandline.Commands, path []string, used map[string]bool) {
+	for _, name := range commands.CommandNames() {
+		var cmd = commands.MustGetCommand(name)
+		var def = handlerDef{
+			cmd:  cmd,
+			path: append(append([]string{}, path...), name),
+		}
+		var ident string
+		for _, elem := range def.path {
+			ident += exportedIdent(elem)
+		}
+		if ident == "" {
+			ident = "Root"
+		}
+		def.name = ident
+		for i := 2; used[def.name] || used[def.name+"Context"]; i++ {
+			def.name = ident + strconv.Itoa(i)
+		}
+		used[def.name], used[def.name+"Context"] = true, true
+		*defs = append(*defs, def)
+		collectHandlers(defs, cmd.Commands, def.path, used)
+	}
+}
+
+// writeContext writes the Context type and accessors of def to buf.
+func writeContext(buf *bytes.Buffer, def handlerDef, pkg string, imports map[string]bool) {
+	var typename = def.name + "Context"
+	fmt.Fprintf(buf, "// %s is the Context of the %s.\n", typename, commandTitle(def.path))
+	fmt.Fprintf(buf, "type %s struct{ commandline.Context }\n\n", typename)
+	// Accessors must not shadow Context methods.
+	var used = make(map[string]bool)
+	var t = reflect.TypeOf((*commandline.Context)(nil)).Elem()
+	for i := 0; i < t.NumMethod(); i++ {
+		used[t.Method(i).Name] = true
+	}
+	var params = def.cmd.Parameters
+	for _, long := range params.ParameterNames() {
+		var param, _ = params.GetParameter(long)
+		var ident = exportedIdent(long)
+		if ident == "" {
+			ident = "Param"
+		}
+		var method = ident
+		if used[method] {
+			method = ident + "Param"
+		}
+		for i := 2; used[method]; i++ {
+			method = ident + "Param" + strconv.Itoa(i)
+		}
+		used[method] = true
+		var result, expr string
+		var quoted = strconv.Quote(long)
+		switch {
+		case param.Value() != nil:
+			result = typeString(reflect.TypeOf(param.Value()).Elem(), pkg, imports)
+			expr = fmt.Sprintf("c.Get(%s).(%s)", quoted, result)
+		case param.Counter():
+			result, expr = "int", fmt.Sprintf("c.Count(%s)", quoted)
+		case param.Negatable():
+			result, expr = "bool", fmt.Sprintf("c.Value(%s) != \"false\"", quoted)
+		case param.Repeated():
+			result, expr = "[]string", fmt.Sprintf("c.Values(%s)", quoted)
+		case param.Raw():
+			result, expr = "string", fmt.Sprintf("c.Value(%s)", quoted)
+		default:
+			result, expr = "bool", fmt.Sprintf("c.Parsed(%s)", quoted)
+		}
+		fmt.Fprintf(buf, "// %s returns the value of %s parameter.\n", method, quoted)
+		fmt.Fprintf(buf, "func (c %s) %s() %s { return %s }\n\n", typename, method, result, expr)
+	}
+}
+
+// typeString returns Go source of type t as referenced from package pkg,
+// adding imports of named types declared in other packages to imports.
+func typeString(t reflect.Type, pkg string, imports map[string]bool) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		var name = strings.TrimSuffix(t.String(), "."+t.Name())
+		if name == pkg {
+			return t.Name()
+		}
+		imports[t.PkgPath()] = true
+		return name + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + typeString(t.Elem(), pkg, imports)
+	case reflect.Slice:
+		return "[]" + typeString(t.Elem(), pkg, imports)
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeString(t.Elem(), pkg, imports))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", typeString(t.Key(), pkg, imports), typeString(t.Elem(), pkg, imports))
+	}
+	return t.String()
+}
+
+// commandTitle returns a description of a Command by path used in comments.
+func commandTitle(path []string) string {
+	var name = strings.TrimSpace(strings.Join(path, " "))
+	if name == "" {
+		return "root Command"
+	}
+	return fmt.Sprintf("%s Command", strconv.Quote(name))
+}
+
+// exportedIdent returns s converted to an exported Go identifier by
+// capitalizing words separated by non letter or digit characters, e.g.
+// "dry-run" to "DryRun". Returns an empty string if s has no letters or
+// digits. Identifiers starting with a digit are prefixed with "X".
+func exportedIdent(s string) string {
+	var sb = &strings.Builder{}
+	var upper = true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	var ident = sb.String()
+	if ident != "" && unicode.IsDigit([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+	return ident
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package gen
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vedranvuk/commandline"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// Test generating handlers.
+func TestHandlers(t *testing.T) {
+	var cl = commandline.NewState()
+	var buf = &bytes.Buffer{}
+	var err error
+	if err = Handlers(buf, "main", cl.Commands); err == nil {
+		t.Fatal("Failed detecting no commands.")
+	}
+	cl.MustAddCommand("", "", nil).
+		MustAddCounterParam("verbose", "v", "", nil).
+		MustAddNegatableParam("color", "", "", nil)
+	cl.MustAddCommand("serve", "", nil).
+		MustAddParam("port", "p", "", false, new(int)).
+		MustAddParam("timeout", "", "", false, new(time.Duration)).
+		MustAddParam("name", "", "", false, new(string)).
+		MustAddParam("dry-run", "", "", false, nil).
+		MustAddRepeatedParam("tag", "", "", false, new([]string)).
+		MustAddRawParam("root", "", true, nil).
+		MustAddVariadicParam("files", "", 0, 0, nil)
+	cl.MustAddCommand("remote", "", nil).
+		MustAddCommand("add", "", nil).
+		MustAddParam("headers", "", "", false, new(map[string]string))
+	cl.MustAddCommand("remote-add", "", nil)
+	cl.MustAddCommand("handlers", "", nil)
+	if err = Handlers(buf, "main", cl.Commands); err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err = ioutil.WriteFile("testdata/handlers.golden", buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	var expected []byte
+	if expected, err = ioutil.ReadFile("testdata/handlers.golden"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatal("Output does not match golden file.")
+	}
+}
+
+// program is a main package that registers the Commands of TestHandlers,
+// implements generated handlers of the golden file using typed accessors
+// and parses arguments given to it.
+const program = `package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vedranvuk/commandline"
+)
+
+type handlers struct{ UnimplementedHandlers }
+
+func (h *handlers) Root(ctx RootContext) error {
+	var verbose int = ctx.Verbose()
+	var color bool = ctx.Color()
+	fmt.Println("root", verbose, color)
+	return nil
+}
+
+func (h *handlers) Serve(ctx ServeContext) error {
+	var port int = ctx.Port()
+	var timeout time.Duration = ctx.Timeout()
+	var files []string = ctx.Files()
+	fmt.Println("serve", port, timeout, files)
+	return nil
+}
+
+func main() {
+	var cl = commandline.NewState()
+	cl.MustAddCommand("", "", nil).
+		MustAddCounterParam("verbose", "v", "", nil).
+		MustAddNegatableParam("color", "", "", nil)
+	cl.MustAddCommand("serve", "", nil).
+		MustAddParam("port", "p", "", false, new(int)).
+		MustAddParam("timeout", "", "", false, new(time.Duration)).
+		MustAddParam("name", "", "", false, new(string)).
+		MustAddParam("dry-run", "", "", false, nil).
+		MustAddRepeatedParam("tag", "", "", false, new([]string)).
+		MustAddRawParam("root", "", true, nil).
+		MustAddVariadicParam("files", "", 0, 0, nil)
+	cl.MustAddCommand("remote", "", nil).
+		MustAddCommand("add", "", nil).
+		MustAddParam("headers", "", "", false, new(map[string]string))
+	cl.MustAddCommand("remote-add", "", nil)
+	cl.MustAddCommand("handlers", "", nil)
+	SetHandlers(cl.Commands, &handlers{})
+	if err := cl.Parse(os.Args[1:]); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+`
+
+// Test generated handlers compile and are called by running the golden file
+// with a program that implements them in a temporary module.
+func TestHandlersBuild(t *testing.T) {
+	var gobin, err = exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	var root string
+	if root, err = filepath.Abs(".."); err != nil {
+		t.Fatal(err)
+	}
+	var dir string
+	if dir, err = ioutil.TempDir("", "commandline-gen"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var sum, src []byte
+	if sum, err = ioutil.ReadFile(filepath.Join(root, "go.sum")); err != nil {
+		t.Fatal(err)
+	}
+	if src, err = ioutil.ReadFile("testdata/handlers.golden"); err != nil {
+		t.Fatal(err)
+	}
+	var mod = fmt.Sprintf("module example\n\ngo 1.15\n\nrequire github.com/vedranvuk/commandline v0.0.0\n\nreplace github.com/vedranvuk/commandline => %s\n", root)
+	var files = map[string][]byte{
+		"go.mod":          []byte(mod),
+		"go.sum":          sum,
+		"handlers_gen.go": src,
+		"main.go":         []byte(program),
+	}
+	for name, data := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var cmd = exec.Command(gobin, "run", ".", "-vv", "--no-color", "serve", "-p", "80", "/srv", "a", "b")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	var out []byte
+	if out, err = cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Running generated handlers failed: %v\n%s", err, out)
+	}
+	if expected := "root 2 false\nserve 80 0s [a b]\n"; string(out) != expected {
+		t.Fatalf("Generated handlers output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package naming implements name conversions shared by commandline and its'
+// generators.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KebabCase returns s converted from CamelCase to kebab-case, e.g.
+// "ListenPort" to "listen-port" and "HTTPServer" to "http-server".
+func KebabCase(s string) string {
+	var runes = []rune(s)
+	var sb = &strings.Builder{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteRune('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
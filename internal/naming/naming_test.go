@@ -0,0 +1,14 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package naming
+
+import "testing"
+
+// Test kebab case conversion.
+func TestKebabCase(t *testing.T) {
+	if KebabCase("ListenPort") != "listen-port" || KebabCase("HTTPServer") != "http-server" || KebabCase("DB") != "db" {
+		t.Fatal("Invalid kebab case.")
+	}
+}
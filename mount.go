@@ -0,0 +1,199 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vedranvuk/commandline/internal/naming"
+)
+
+var (
+	// contextType is the reflect type of Context.
+	contextType = reflect.TypeOf((*Context)(nil)).Elem()
+	// errorType is the reflect type of error.
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// AddCommandFunc is like AddCommand except handler is a function of one of
+// the following signatures:
+//
+//	func(Context) error
+//	func(*Opts) error
+//	func(Context, *Opts) error
+//
+// where Opts is a struct type whose fields are bound as Command Parameters
+// using Bind. Handler is called with a new copy of the bound Opts value
+// filled from parsed Parameters so it may retain it. If handler has a
+// different signature ErrHandlerSignature is returned. If an error occurs it
+// is ErrRegister or a descendant and the Command is not registered.
+func (c *Commands) AddCommandFunc(name, help string, handler interface{}) (*Command, error) {
+	var h, opts, err = funcHandler(reflect.ValueOf(handler))
+	if err != nil {
+		return nil, err
+	}
+	var cmd *Command
+	if cmd, err = c.addCommand(name, help, h, false); err != nil {
+		return nil, err
+	}
+	if !opts.IsValid() {
+		return cmd, nil
+	}
+	if err = cmd.Bind(opts.Interface()); err != nil {
+		c.removeCommand(name)
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// MustAddCommandFunc is like AddCommandFunc except the function panics on
+// error. Returns the registered Command.
+func (c *Commands) MustAddCommandFunc(name, help string, handler interface{}) *Command {
+	var cmd, err = c.AddCommandFunc(name, help, handler)
+	if err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+// Mount registers a Command in these Commands for each exported method of v
+// with a signature supported by AddCommandFunc, in order of method names.
+// Command name is the method name in kebab-case, e.g. "AddUser" becomes
+// "add-user". Methods with other signatures are skipped.
+//
+// Command help is taken from the map returned by an optional
+// "Help() map[string]string" method of v, keyed by method name or Command
+// name, or if not found there from the help tag of a blank field of Opts,
+// e.g.:
+//
+//	type AddUserOpts struct {
+//		_    struct{} `help:"Add a user."`
+//		Name string   `cmd:"name,n,required"`
+//	}
+//
+// If an error occurs it is ErrRegister or a descendant and no Commands of
+// methods of v are registered.
+func (c *Commands) Mount(v interface{}) error {
+	var rv = reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		return fmt.Errorf("%w: invalid mount value", ErrRegister)
+	}
+	var help map[string]string
+	if h, ok := v.(interface{ Help() map[string]string }); ok {
+		help = h.Help()
+	}
+	var t = rv.Type()
+	var method reflect.Method
+	var handler Handler
+	var opts reflect.Value
+	var name, text string
+	var cmd *Command
+	var mounted []string
+	var err error
+	for i := 0; i < t.NumMethod(); i++ {
+		if method = t.Method(i); method.PkgPath != "" {
+			continue
+		}
+		if handler, opts, err = funcHandler(rv.Method(i)); err != nil {
+			continue
+		}
+		name = naming.KebabCase(method.Name)
+		if text = help[method.Name]; text == "" {
+			text = help[name]
+		}
+		if text == "" && opts.IsValid() {
+			text = optsHelp(opts.Type().Elem())
+		}
+		if cmd, err = c.AddCommand(name, text, handler); err != nil {
+			c.unmount(mounted)
+			return err
+		}
+		mounted = append(mounted, name)
+		if opts.IsValid() {
+			if err = cmd.Bind(opts.Interface()); err != nil {
+				c.unmount(mounted)
+				return err
+			}
+		}
+	}
+	if len(mounted) == 0 {
+		return fmt.Errorf("%w: no methods to mount", ErrRegister)
+	}
+	return nil
+}
+
+// unmount removes Commands registered under names by Mount.
+func (c *Commands) unmount(names []string) {
+	for _, name := range names {
+		c.removeCommand(name)
+	}
+}
+
+// MustMount is like Mount except the function panics on error.
+// Returns the Commands.
+func (c *Commands) MustMount(v interface{}) *Commands {
+	var err error
+	if err = c.Mount(v); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// funcHandler returns a Handler that calls function f of a signature
+// supported by AddCommandFunc and a pointer to a new Opts value to bind,
+// invalid if f takes no Opts. F is called with a copy of the bound value.
+// Returns ErrHandlerSignature if f is not a function of a supported
+// signature.
+func funcHandler(f reflect.Value) (Handler, reflect.Value, error) {
+	var opts reflect.Value
+	if !f.IsValid() || f.Kind() != reflect.Func || f.IsNil() {
+		return nil, opts, fmt.Errorf("%w: handler is not a function", ErrHandlerSignature)
+	}
+	var t = f.Type()
+	if t.IsVariadic() || t.NumOut() != 1 || t.Out(0) != errorType || t.NumIn() < 1 || t.NumIn() > 2 {
+		return nil, opts, fmt.Errorf("%w: %s", ErrHandlerSignature, t)
+	}
+	var withContext = t.In(0) == contextType
+	var last = t.In(t.NumIn() - 1)
+	if t.NumIn() == 2 && !withContext {
+		return nil, opts, fmt.Errorf("%w: %s", ErrHandlerSignature, t)
+	}
+	if t.NumIn() == 2 || !withContext {
+		if last.Kind() != reflect.Ptr || last.Elem().Kind() != reflect.Struct {
+			return nil, opts, fmt.Errorf("%w: %s", ErrHandlerSignature, t)
+		}
+		opts = reflect.New(last.Elem())
+	}
+	var handler = func(ctx Context) error {
+		var in []reflect.Value
+		if withContext {
+			in = append(in, reflect.ValueOf(&ctx).Elem())
+		}
+		if opts.IsValid() {
+			var value = reflect.New(opts.Type().Elem())
+			value.Elem().Set(opts.Elem())
+			in = append(in, value)
+		}
+		if out := f.Call(in); !out[0].IsNil() {
+			return out[0].Interface().(error)
+		}
+		return nil
+	}
+	return handler, opts, nil
+}
+
+// optsHelp returns the help tag of the first blank field of struct type t
+// or an empty string if not found.
+func optsHelp(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Name == "_" {
+			if help := field.Tag.Get("help"); help != "" {
+				return help
+			}
+		}
+	}
+	return ""
+}
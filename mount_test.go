@@ -0,0 +1,198 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// addUserOpts are options of the add-user test command.
+type addUserOpts struct {
+	_     struct{} `help:"Add a user."`
+	Name  string   `cmd:"name,n,required"`
+	Admin bool     `cmd:"admin,,negatable"`
+}
+
+// service is a test mount value.
+type service struct {
+	added  string
+	listed bool
+}
+
+// AddUser adds a user.
+func (s *service) AddUser(opts *addUserOpts) error {
+	s.added = opts.Name
+	return nil
+}
+
+// ListUsers lists users.
+func (s *service) ListUsers(ctx Context) error {
+	s.listed = ctx.Executed()
+	return nil
+}
+
+// Fail fails.
+func (s *service) Fail(ctx Context) error { return errors.New("failed") }
+
+// Unsupported is not mounted.
+func (s *service) Unsupported(name string) error { return nil }
+
+// Help returns help of commands.
+func (s *service) Help() map[string]string {
+	return map[string]string{"ListUsers": "List users.", "fail": "Always fails."}
+}
+
+// conflictOpts are options with conflicting param names.
+type conflictOpts struct {
+	A int `cmd:"a,,counter"`
+	B int `cmd:"a"`
+}
+
+// conflict is a test mount value with invalid opts.
+type conflict struct{}
+
+// Check is mounted before Conflict.
+func (c conflict) Check(ctx Context) error { return nil }
+
+// Conflict has opts with conflicting param names.
+func (c conflict) Conflict(opts *conflictOpts) error { return nil }
+
+// Test mounting methods as commands.
+func TestMount(t *testing.T) {
+	var svc = &service{}
+	var cl = NewState()
+	var err error
+	cl.MustAddCommand("users", "", nil).MustMount(svc)
+	var users = cl.MustGetCommand("users")
+	if users.CommandCount() != 3 {
+		t.Fatal("Invalid number of mounted commands.")
+	}
+	if _, ok := users.GetCommand("unsupported"); ok {
+		t.Fatal("Mounted unsupported method.")
+	}
+	if users.MustGetCommand("add-user").Help() != "Add a user." ||
+		users.MustGetCommand("list-users").Help() != "List users." ||
+		users.MustGetCommand("fail").Help() != "Always fails." {
+		t.Fatal("Invalid mounted help.")
+	}
+	if err = cl.Parse([]string{"users", "add-user", "-n", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if svc.added != "bob" {
+		t.Fatal("Opts method not called.")
+	}
+	if err = cl.Parse([]string{"users", "add-user"}); !errors.Is(err, ErrParse) {
+		t.Fatal("Failed detecting missing required bound param.")
+	}
+	if err = cl.Parse([]string{"users", "list-users"}); err != nil {
+		t.Fatal(err)
+	}
+	if !svc.listed {
+		t.Fatal("Context method not called.")
+	}
+	if err = cl.Parse([]string{"users", "fail"}); err == nil || err.Error() != "failed" {
+		t.Fatal("Method error not returned.")
+	}
+	if !strings.Contains(cl.Print(), "[--[no-]admin]") {
+		t.Fatal("Bound params not printed.")
+	}
+	if err = cl.Mount(nil); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting invalid value.")
+	}
+	if err = cl.Mount(struct{}{}); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting value without methods.")
+	}
+	if err = users.Mount(svc); !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Failed detecting duplicate command.")
+	}
+	if err = cl.Mount(conflict{}); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting invalid opts.")
+	}
+	if _, ok := cl.GetCommand("conflict"); ok {
+		t.Fatal("Mounted command with invalid opts.")
+	}
+	if _, ok := cl.GetCommand("check"); ok {
+		t.Fatal("Mounted command left registered after an error.")
+	}
+}
+
+// Test registering typed handler functions.
+func TestAddCommandFunc(t *testing.T) {
+	var cl = NewState()
+	var err error
+	var name string
+	var executed bool
+	cl.MustAddCommandFunc("add", "", func(opts *addUserOpts) error {
+		name = opts.Name
+		return nil
+	})
+	cl.MustAddCommandFunc("both", "", func(ctx Context, opts *addUserOpts) error {
+		name, executed = opts.Name, ctx.Executed()
+		return nil
+	})
+	cl.MustAddCommandFunc("ctx", "", func(ctx Context) error {
+		return errors.New("failed")
+	})
+	if err = cl.Parse([]string{"add", "--name", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if name != "bob" {
+		t.Fatal("Opts func not called.")
+	}
+	if err = cl.Parse([]string{"both", "-n", "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if name != "alice" || !executed {
+		t.Fatal("Context and opts func not called.")
+	}
+	if err = cl.Parse([]string{"ctx"}); err == nil || err.Error() != "failed" {
+		t.Fatal("Func error not returned.")
+	}
+	// Each call gets a new opts value.
+	var retained []*addUserOpts
+	cl.MustAddCommandFunc("retain", "", func(opts *addUserOpts) error {
+		retained = append(retained, opts)
+		return nil
+	})
+	if err = cl.Parse([]string{"retain", "-n", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cl.Parse([]string{"retain", "-n", "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if retained[0] == retained[1] || retained[0].Name != "bob" || retained[1].Name != "alice" {
+		t.Fatal("Opts value reused between calls.")
+	}
+	var tests = []interface{}{
+		nil,
+		42,
+		func() error { return nil },
+		func(Context) {},
+		func(Context) string { return "" },
+		func(*addUserOpts, Context) error { return nil },
+		func(Context, addUserOpts) error { return nil },
+		func(Context, *int) error { return nil },
+		func(Context, *addUserOpts, int) error { return nil },
+		func(...Context) error { return nil },
+	}
+	for _, test := range tests {
+		if _, err = cl.AddCommandFunc("invalid", "", test); !errors.Is(err, ErrHandlerSignature) {
+			t.Fatal("Failed detecting invalid handler signature.")
+		}
+	}
+	if _, err = cl.AddCommandFunc("invalid", "", func(*struct {
+		A int `cmd:"a,,counter"`
+		B int `cmd:"a"`
+	}) error {
+		return nil
+	}); !errors.Is(err, ErrRegister) {
+		t.Fatal("Failed detecting invalid opts.")
+	}
+	if _, ok := cl.GetCommand("invalid"); ok {
+		t.Fatal("Registered command with invalid opts.")
+	}
+}
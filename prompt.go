@@ -0,0 +1,139 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SetSecret marks a Param registered under specified long name as secret.
+// When prompted, value of a secret Param is read without echo if the prompt
+// reader is a terminal. If an error occurs it is ErrRegister or a descendant.
+func (p *Parameters) SetSecret(long string) error {
+	var param, ok = p.longparams[long]
+	if !ok || param.name != long {
+		return fmt.Errorf("%w: parameter '%s' not found", ErrRegister, long)
+	}
+	param.secret = true
+	return nil
+}
+
+// MustSetSecret is like SetSecret except the function panics on error.
+// Returns a Command that the param was marked secret on.
+func (p *Parameters) MustSetSecret(long string) *Command {
+	var err error
+	if err = p.SetSecret(long); err != nil {
+		panic(err)
+	}
+	return p.cmd
+}
+
+// prompt prompts for values of required Parameters that were not parsed in
+// order of registration. Prompt is the Param help or long name if help is
+// empty followed by choices and the default value, if any. An empty answer
+// selects the default value. Answers are converted and validated as if given
+// on command line and the Param is prompted for again if invalid. Prompting
+// for a Param stops if reading fails, leaving it not parsed.
+func (p *Parameters) prompt(state *State) {
+	var r, w = state.PromptReader, state.PromptWriter
+	if r == nil {
+		r = os.Stdin
+	}
+	if w == nil {
+		w = os.Stdout
+	}
+	var param *Parameter
+	var answer string
+	var err error
+	for _, long := range p.longindexes {
+		if param = p.longparams[long]; !param.required || param.parsed || param.variadic {
+			continue
+		}
+		for !param.parsed {
+			fmt.Fprint(w, promptText(long, param))
+			if answer, err = readAnswer(r, w, param.secret); err != nil {
+				break
+			}
+			if answer == "" {
+				if answer = param.defstring; answer == "" {
+					continue
+				}
+			}
+			if err = param.apply([]string{answer}, state.converters); err == nil {
+				err = param.validate()
+			}
+			if err != nil {
+				param.reset()
+				fmt.Fprintf(w, "Invalid value: %v\n", err)
+				continue
+			}
+			param.mark(SourcePrompt, -1)
+		}
+	}
+}
+
+// promptText returns the prompt for a Param registered under long name.
+func promptText(long string, param *Parameter) string {
+	var sb = &strings.Builder{}
+	if param.help != "" {
+		sb.WriteString(param.help)
+	} else {
+		sb.WriteString(long)
+	}
+	if len(param.choices) > 0 {
+		sb.WriteString(" [")
+		sb.WriteString(strings.Join(param.choices, "/"))
+		sb.WriteRune(']')
+	}
+	if param.defstring != "" {
+		sb.WriteString(" (default: ")
+		sb.WriteString(param.defstring)
+		sb.WriteRune(')')
+	}
+	sb.WriteString(": ")
+	return sb.String()
+}
+
+// readAnswer reads a line from r and returns it trimmed of surrounding
+// space. If secret is true and r is a terminal the line is read without
+// echo and a new line is written to w after reading. Returns an error if
+// reading failed or r is exhausted before any input was read.
+func readAnswer(r io.Reader, w io.Writer, secret bool) (string, error) {
+	if f, ok := r.(*os.File); ok && secret && isTerminal(f.Fd()) {
+		var answer, err = readNoEcho(f)
+		fmt.Fprintln(w)
+		return strings.TrimSpace(answer), err
+	}
+	var answer, err = readLine(r)
+	return strings.TrimSpace(answer), err
+}
+
+// readLine reads a line from r one byte at a time so that no input past the
+// line is consumed. Returns an error if reading failed or r is exhausted
+// before any input was read.
+func readLine(r io.Reader) (string, error) {
+	var sb = &strings.Builder{}
+	var buf = make([]byte, 1)
+	var n int
+	var err error
+	for {
+		if n, err = r.Read(buf); n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			sb.WriteByte(buf[0])
+		}
+		if err != nil {
+			if err == io.EOF && sb.Len() > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\r"), nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Test prompting for missing required params.
+func TestPrompt(t *testing.T) {
+	var port int
+	var format = "json"
+	var password string
+	var sources = make(map[string]Source)
+	var out = &bytes.Buffer{}
+	var state = NewState()
+	var err error
+	state.Interactive = true
+	state.PromptWriter = out
+	state.MustAddCommand("serve", "", func(ctx Context) error {
+		for _, name := range []string{"port", "format", "password"} {
+			sources[name] = ctx.Source(name)
+		}
+		return nil
+	}).
+		MustAddParam("port", "p", "Port to listen on", true, &port).
+		MustAddValidator("port", func(v interface{}) error {
+			if v.(int) < 1 {
+				return errors.New("port out of range")
+			}
+			return nil
+		}).
+		MustAddParam("format", "f", "", true, &format).
+		MustSetChoices("format", "json", "yaml").
+		MustAddParam("password", "", "", true, &password).
+		MustSetSecret("password")
+	// Invalid, out of range, then valid port; default format.
+	state.PromptReader = strings.NewReader("x\n0\n80\n\nsecret\n")
+	if err = state.Parse([]string{"serve"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 80 || format != "json" || password != "secret" {
+		t.Fatal("Values not read from prompt.")
+	}
+	if sources["port"] != SourcePrompt || sources["password"] != SourcePrompt {
+		t.Fatal("Invalid prompt source.")
+	}
+	var text = out.String()
+	if !strings.Contains(text, "Port to listen on: ") ||
+		!strings.Contains(text, "format [json/yaml] (default: json): ") ||
+		!strings.Contains(text, "password: ") {
+		t.Fatal("Invalid prompts.")
+	}
+	if strings.Count(text, "Invalid value") != 2 {
+		t.Fatal("Invalid answers not reported.")
+	}
+	// Params given on command line are not prompted.
+	out.Reset()
+	state.PromptReader = strings.NewReader("pass\n")
+	if err = state.Parse([]string{"serve", "-p", "8080", "-f", "yaml"}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "Port") || port != 8080 || password != "pass" {
+		t.Fatal("Prompted for a parsed param.")
+	}
+	// Exhausted input.
+	state.PromptReader = strings.NewReader("80\n")
+	if err = state.Parse([]string{"serve"}); !errors.Is(err, ErrParse) {
+		t.Fatal("Failed detecting missing required param.")
+	}
+	// Invalid arguments fail before prompting.
+	out.Reset()
+	state.PromptReader = strings.NewReader("80\njson\nsecret\n")
+	if err = state.Parse([]string{"serve", "extra"}); !errors.Is(err, ErrExtraArguments) {
+		t.Fatal("Failed detecting extra arguments.")
+	}
+	if out.Len() != 0 {
+		t.Fatal("Prompted before all arguments were parsed.")
+	}
+	// Not interactive.
+	state.Interactive = false
+	state.PromptReader = strings.NewReader("80\njson\nsecret\n")
+	if err = state.Parse([]string{"serve"}); !errors.Is(err, ErrParse) {
+		t.Fatal("Prompted when not interactive.")
+	}
+}
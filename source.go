@@ -0,0 +1,129 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Source defines the source of a Parameter value.
+//
+// A Parameter not given a value from command line, environment,
+// configuration or a prompt has SourceDefault if it has a default value and
+// SourceNone otherwise. A Parameter with a Go value always has a default
+// value; it is the value set using SetDefault or a default tag of a bound
+// field or if none, the Go value when the Parameter was registered, even if
+// zero. A Parameter without a Go value has a default value only if set using
+// SetDefault, except negatable Parameters which default to true.
+type Source int
+
+const (
+	// SourceNone represents no value.
+	SourceNone Source = iota
+	// SourceDefault represents a default value.
+	SourceDefault
+	// SourceEnvironment represents a value read from environment.
+	SourceEnvironment
+	// SourceConfig represents a value loaded from configuration.
+	SourceConfig
+	// SourceCommandLine represents a value parsed from command line.
+	SourceCommandLine
+	// SourcePrompt represents a value read from an interactive prompt.
+	SourcePrompt
+)
+
+// String implements stringer on Source.
+func (s Source) String() string {
+	switch s {
+	case SourceNone:
+		return "none"
+	case SourceDefault:
+		return "default"
+	case SourceEnvironment:
+		return "environment"
+	case SourceConfig:
+		return "config"
+	case SourceCommandLine:
+		return "command line"
+	case SourcePrompt:
+		return "prompt"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler on Source.
+func (s Source) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// SourceInfo describes the value of a Parameter of a matched Command and
+// where it came from.
+type SourceInfo struct {
+	// Command is the dot separated path of the Command.
+	Command string `json:"command"`
+	// Param is the Parameter long name.
+	Param string `json:"param"`
+	// Source is the source of the value.
+	Source Source `json:"source"`
+	// Value is the raw value or the default value as displayed.
+	Value string `json:"value"`
+	// Values are all raw values of a Parameter given multiple times.
+	Values []string `json:"values,omitempty"`
+	// Position is the index of the argument in command line arguments the
+	// Parameter was last specified in, not of a value argument following it,
+	// or -1 if not parsed from command line.
+	Position int `json:"position"`
+}
+
+// Sources is a list of SourceInfo. It can be marshaled to JSON using
+// encoding/json.
+type Sources []SourceInfo
+
+// String returns Sources as a table suitable for terminal display.
+func (s Sources) String() string {
+	var sb = &strings.Builder{}
+	var tw = tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	var position string
+	fmt.Fprintln(tw, "COMMAND\tPARAM\tSOURCE\tVALUE\tPOSITION")
+	for _, info := range s {
+		if position = "-"; info.Position >= 0 {
+			position = fmt.Sprint(info.Position)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", info.Command, info.Param, info.Source, info.Value, position)
+	}
+	tw.Flush()
+	return sb.String()
+}
+
+// Sources returns sources of values of Parameters of Commands matched by
+// last Parse in order of matching and Parameter registration.
+func (state *State) Sources() Sources {
+	var result Sources
+	var path []string
+	var param *Parameter
+	var info SourceInfo
+	for _, cmd := range state.matches {
+		path = appendPath(path, cmd.name)
+		for _, long := range cmd.Parameters.longindexes {
+			param = cmd.Parameters.longparams[long]
+			info = SourceInfo{
+				Command:  strings.Join(path, "."),
+				Param:    long,
+				Source:   param.source,
+				Position: param.position,
+			}
+			if param.parsed {
+				info.Value = param.rawvalue
+				if param.multiple() {
+					info.Values = append([]string(nil), param.rawvalues...)
+				}
+			} else {
+				info.Value = param.defstring
+			}
+			result = append(result, info)
+		}
+	}
+	return result
+}
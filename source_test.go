@@ -0,0 +1,119 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package commandline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Test value source tracking.
+func TestSources(t *testing.T) {
+	var port int
+	var host, user = "", "admin"
+	var sources = make(map[string]Source)
+	var state = NewState()
+	var err error
+	state.LookupEnv = func(key string) (string, bool) {
+		if key == "HOST" {
+			return "localhost", true
+		}
+		return "", false
+	}
+	state.MustAddCommand("", "", nil).
+		MustAddCounterParam("debug", "d", "", nil)
+	state.MustAddCommand("serve", "", func(ctx Context) error {
+		for _, name := range []string{"port", "host", "user", "tag", "dry"} {
+			sources[name] = ctx.Source(name)
+		}
+		return nil
+	}).
+		MustAddParam("port", "p", "", false, &port).
+		MustAddParam("host", "", "", false, &host).
+		MustSetEnv("host", "HOST").
+		MustAddParam("user", "", "", false, &user).
+		MustAddRepeatedParam("tag", "t", "", false, new([]string)).
+		MustAddParam("dry", "", "", false, nil)
+	if err = state.LoadJSON(strings.NewReader(`{"serve": {"tag": ["a", "b"]}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.Parse([]string{"-dd", "serve", "--port", "80"}); err != nil {
+		t.Fatal(err)
+	}
+	if sources["port"] != SourceCommandLine || sources["host"] != SourceEnvironment ||
+		sources["user"] != SourceDefault || sources["tag"] != SourceConfig || sources["dry"] != SourceNone {
+		t.Fatal("Invalid sources.")
+	}
+	var list = state.Sources()
+	if len(list) != 6 {
+		t.Fatal("Invalid number of sources.")
+	}
+	if list[0].Command != "" || list[0].Param != "debug" || list[0].Value != "2" || list[0].Position != 0 {
+		t.Fatal("Invalid counter source.")
+	}
+	if list[1].Command != "serve" || list[1].Value != "80" || list[1].Position != 2 {
+		t.Fatal("Invalid command line source.")
+	}
+	// Position is of the param argument whether the value is attached or not.
+	for _, args := range [][]string{{"serve", "--port", "80"}, {"serve", "--port=80"}, {"serve", "-p", "80"}} {
+		if err = state.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		for _, info := range state.Sources() {
+			if info.Param == "port" && info.Position != 1 {
+				t.Fatalf("Invalid position of %v: %d", args, info.Position)
+			}
+		}
+	}
+	if list[2].Value != "localhost" || list[2].Position != -1 {
+		t.Fatal("Invalid environment source.")
+	}
+	if list[3].Value != "admin" || list[4].Value != "b" || len(list[4].Values) != 2 {
+		t.Fatal("Invalid source values.")
+	}
+	var text = list.String()
+	if !strings.Contains(text, "environment") || !strings.Contains(text, "command line") {
+		t.Fatal("Sources not printed.")
+	}
+	var data []byte
+	if data, err = json.Marshal(list); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"source":"config"`) {
+		t.Fatal("Sources not marshaled.")
+	}
+}
+
+// Test sources of default values.
+func TestDefaultSources(t *testing.T) {
+	var options struct {
+		Zero    int    `cmd:"zero"`
+		Initial string `cmd:"initial"`
+		Tagged  int    `cmd:"tagged" default:"3"`
+	}
+	options.Initial = "foo"
+	var state = NewState()
+	var err error
+	state.MustAddCommand("foo", "", nil).
+		MustBind(&options).
+		MustAddParam("flag", "", "", false, nil).
+		MustAddParam("mode", "", "", false, nil).
+		MustSetDefault("mode", "fast").
+		MustAddNegatableParam("color", "", "", nil)
+	if err = state.Parse([]string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	var sources = make(map[string]Source)
+	for _, info := range state.Sources() {
+		sources[info.Param] = info.Source
+	}
+	if sources["zero"] != SourceDefault || sources["initial"] != SourceDefault || sources["tagged"] != SourceDefault {
+		t.Fatal("Invalid sources of Go value defaults.")
+	}
+	if sources["flag"] != SourceNone || sources["mode"] != SourceDefault || sources["color"] != SourceDefault {
+		t.Fatal("Invalid sources of params without Go values.")
+	}
+}
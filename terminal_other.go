@@ -0,0 +1,18 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package commandline
+
+import "os"
+
+// isTerminal returns false as terminals are not detected on this platform
+// and secret values are read with echo.
+func isTerminal(fd uintptr) bool { return false }
+
+// readNoEcho reads a line from f. Disabling echo is not supported on this
+// platform.
+func readNoEcho(f *os.File) (string, error) { return readLine(f) }
@@ -0,0 +1,55 @@
+// Copyright 2020 Vedran Vuk. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package commandline
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// getTermios returns terminal attributes of fd or an error if fd is not a
+// terminal.
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+	var termios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		return nil, errno
+	}
+	return &termios, nil
+}
+
+// setTermios sets terminal attributes of fd.
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal returns true if fd is a terminal.
+func isTerminal(fd uintptr) bool {
+	var _, err = getTermios(fd)
+	return err == nil
+}
+
+// readNoEcho reads a line from terminal f with echo disabled.
+func readNoEcho(f *os.File) (string, error) {
+	var fd = f.Fd()
+	var old, err = getTermios(fd)
+	if err != nil {
+		return "", err
+	}
+	var termios = *old
+	termios.Lflag &^= syscall.ECHO
+	termios.Lflag |= syscall.ICANON | syscall.ISIG
+	if err = setTermios(fd, &termios); err != nil {
+		return "", err
+	}
+	defer setTermios(fd, old)
+	return readLine(f)
+}